@@ -2,14 +2,137 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"tg-bot/models"
+	"time"
 	"unicode/utf8"
 )
 
+// DefaultBaseURL адрес боевого API tourguideyar.ru
+const DefaultBaseURL = "https://tourguideyar.ru/api"
+
+// DefaultMaxPages сколько страниц выдачи загружается по умолчанию
+const DefaultMaxPages = 10
+
+// DefaultTimeout предельное время одной попытки запроса по умолчанию
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxRetries сколько раз по умолчанию повторяется GET-запрос
+const DefaultMaxRetries = 3
+
+// DefaultUserAgent передается в заголовке User-Agent, если не задан свой
+const DefaultUserAgent = "tourguide-tg-bot/1.0"
+
+// metersPerDegree длина одного градуса широты в метрах
+const metersPerDegree = 111320.0
+
+// RadiusFromMeters переводит радиус в метрах в градусы, которые предположительно принимает API карты
+func RadiusFromMeters(meters int) float64 {
+	return float64(meters) / metersPerDegree
+}
+
+// Service описывает операции с API достопримечательностей,
+// от которых зависят обработчики бота
+type Service interface {
+	AttractionsByCity(ctx context.Context, city string) ([]models.Attraction, error)
+	AttractionsByLocation(ctx context.Context, lat, lon float64, radius float64) ([]models.Attraction, error)
+	AttractionDetail(ctx context.Context, id int) (models.AttractionDetail, error)
+}
+
+// Client клиент API tourguideyar.ru
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	maxPages   int
+	timeout    time.Duration
+	maxRetries int
+	retryBase  time.Duration
+	retryLimit time.Duration
+	breaker    *Breaker
+}
+
+// Option настраивает Client
+type Option func(*Client)
+
+// WithBaseURL задает адрес API, например стейджинга или локальной заглушки
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient задает http-клиент для запросов
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent задает значение заголовка User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithMaxPages ограничивает число страниц, которые AttractionsByCity и
+// AttractionsByLocation загружают за один вызов. 0 снимает ограничение
+func WithMaxPages(maxPages int) Option {
+	return func(c *Client) {
+		c.maxPages = maxPages
+	}
+}
+
+// WithTimeout задает предельное время одной попытки запроса
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry задает число повторов GET-запросов после временных ошибок
+// и базовую задержку между ними. maxRetries = 0 отключает повторы
+func WithRetry(maxRetries int, base time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBase = base
+	}
+}
+
+// WithBreaker задает предохранитель. nil отключает его
+func WithBreaker(breaker *Breaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// NewClient создает клиент API с настройками по умолчанию
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{},
+		userAgent:  DefaultUserAgent,
+		maxPages:   DefaultMaxPages,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		retryBase:  300 * time.Millisecond,
+		retryLimit: 5 * time.Second,
+		breaker:    NewBreaker(5, 30*time.Second),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.baseURL = strings.TrimRight(c.baseURL, "/")
+	return c
+}
+
 func cleanUTF8(s string) string {
 	if utf8.ValidString(s) {
 		return s
@@ -27,108 +150,124 @@ func cleanUTF8(s string) string {
 	return string(v)
 }
 
-// GetAttractionsByCity получает достопримечательности по городу
-func GetAttractionsByCity(city string) ([]models.Attraction, error) {
-	// Создаем запрос с городом
-	cityReq := models.CityRequest{
-		City: city,
-	}
-
-	// Конвертируем в JSON
-	jsonData, err := json.Marshal(cityReq)
-	if err != nil {
+// do выполняет запрос и возвращает тело ответа.
+// GET-запросы повторяются после временных ошибок, пока не исчерпаны
+// попытки или не истек ctx. Пока предохранитель разомкнут,
+// сразу возвращается ErrCircuitOpen
+func (c *Client) do(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	if err := c.breaker.Allow(); err != nil {
 		return nil, err
 	}
 
-	// Создаем POST запрос
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/cities/", "https://tourguideyar.ru/api"), bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+	// Повторяем только идемпотентные запросы
+	attempts := 1
+	if method == http.MethodGet {
+		attempts += c.maxRetries
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	// Выполняем запрос
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	for attempt := 0; ; attempt++ {
+		data, err := c.doOnce(ctx, method, url, body)
+		if err == nil {
+			c.breaker.Success()
+			return data, nil
+		}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+		transient := isTransient(ctx, err)
+		if !transient || attempt+1 >= attempts {
+			switch {
+			case transient:
+				c.breaker.Failure()
+			case ctx.Err() != nil:
+				c.breaker.Cancel()
+			default:
+				// API ответило осмысленной ошибкой, значит оно работает
+				c.breaker.Success()
+			}
+			return nil, err
+		}
 
-	// Парсим ответ
-	var cityResponse models.CityAPIResponse
-	err = json.Unmarshal(body, &cityResponse)
-	if err != nil {
-		// Попробуем альтернативный формат ответа
-		var altResponse models.APIResponse
-		if altErr := json.Unmarshal(body, &altResponse); altErr == nil {
-			return altResponse.Results, nil
+		timer := time.NewTimer(backoff(attempt, c.retryBase, c.retryLimit, err))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			c.breaker.Cancel()
+			return nil, ctx.Err()
 		}
-		return nil, err
 	}
-
-	return cityResponse.Attractions, nil
 }
 
-func GetAttractionsByLocation(lat, lon float64, radius float64) ([]models.Attraction, error) {
-	// Формируем URL с query-параметрами
-	url := fmt.Sprintf("%s/map/attractions/?lat=%f&lng=%f&radius=%f",
-		"https://tourguideyar.ru/api", lat, lon, radius)
+// doOnce выполняет одну попытку запроса.
+// Ответ с кодом, отличным от 2xx, возвращается как *StatusError
+func (c *Client) doOnce(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
 
-	// Создаем GET-запрос
-	req, err := http.NewRequest("GET", url, nil)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
 
-	// Выполняем запрос
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxSnippetLength))
+		statusErr := newStatusError(resp.StatusCode, snippet)
+		statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return nil, statusErr
 	}
 
-	// Парсим ответ
-	var mapResponse models.MapAPIResponse
-	err = json.Unmarshal(body, &mapResponse)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// AttractionsByCity получает достопримечательности по городу,
+// проходя по страницам ответа не дальше ограничения maxPages
+// (DefaultMaxPages, если не задано WithMaxPages). Результаты с
+// непрочитанных страниц отбрасываются, об этом пишется в лог.
+// Чтобы узнать об обрезке в коде, используйте CityPager и Pager.Truncated
+func (c *Client) AttractionsByCity(ctx context.Context, city string) ([]models.Attraction, error) {
+	pager, err := c.CityPager(city)
 	if err != nil {
-		// Попробуем альтернативный формат ответа
-		var altResponse models.APIResponse
-		if altErr := json.Unmarshal(body, &altResponse); altErr == nil {
-			return altResponse.Results, nil
-		}
 		return nil, err
 	}
+	return pager.All(ctx, c.maxPages)
+}
 
-	return mapResponse.Attractions, nil
+// AttractionsByLocation получает достопримечательности вокруг точки
+// в радиусе radius градусов (см. RadiusFromMeters), проходя по страницам ответа не дальше ограничения maxPages
+func (c *Client) AttractionsByLocation(ctx context.Context, lat, lon float64, radius float64) ([]models.Attraction, error) {
+	return c.LocationPager(lat, lon, radius).All(ctx, c.maxPages)
 }
 
-func GetAttractionDetail(id int) (models.AttractionDetail, error) {
+// AttractionDetail получает детальную информацию о достопримечательности
+func (c *Client) AttractionDetail(ctx context.Context, id int) (models.AttractionDetail, error) {
 	var detail models.AttractionDetail
 
-	resp, err := http.Get(fmt.Sprintf("%s/attractions/%d/", "https://tourguideyar.ru/api", id))
+	body, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/attractions/%d/", c.baseURL, id), nil)
 	if err != nil {
 		return detail, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return detail, err
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return models.AttractionDetail{}, decodeError(err)
 	}
-
-	err = json.Unmarshal(body, &detail)
 	detail.Name = cleanUTF8(detail.Name)
 	detail.Address = cleanUTF8(detail.Address)
 	detail.City = cleanUTF8(detail.City)
@@ -139,5 +278,5 @@ func GetAttractionDetail(id int) (models.AttractionDetail, error) {
 	detail.Website = cleanUTF8(detail.Website)
 	detail.Cost = cleanUTF8(detail.Cost)
 	detail.MainPhotoURL = cleanUTF8(detail.MainPhotoURL)
-	return detail, err
+	return detail, nil
 }
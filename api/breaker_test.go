@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewBreaker(3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() after %d failures = %v", i, err)
+		}
+		b.Failure()
+	}
+	// Успех сбрасывает счетчик подряд идущих ошибок
+	b.Success()
+	for i := 0; i < 2; i++ {
+		b.Failure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after reset = %v", err)
+	}
+
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() after threshold = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		probe    func(b *Breaker)
+		wantOpen bool
+	}{
+		{name: "probe succeeds", probe: (*Breaker).Success},
+		{name: "probe fails", probe: (*Breaker).Failure, wantOpen: true},
+		{name: "probe cancelled", probe: (*Breaker).Cancel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(1, time.Millisecond)
+			b.Failure()
+			time.Sleep(2 * time.Millisecond)
+
+			if err := b.Allow(); err != nil {
+				t.Fatalf("probe Allow() = %v", err)
+			}
+			// Пока пробный запрос не завершен, остальные отклоняются
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("second Allow() during probe = %v, want ErrCircuitOpen", err)
+			}
+
+			tt.probe(b)
+			b.cooldown = time.Hour
+			err := b.Allow()
+			if tt.wantOpen && !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("Allow() = %v, want ErrCircuitOpen", err)
+			}
+			if !tt.wantOpen && err != nil {
+				t.Errorf("Allow() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var nilBreaker *Breaker
+	for _, b := range []*Breaker{nilBreaker, NewBreaker(0, time.Hour)} {
+		for i := 0; i < 10; i++ {
+			b.Failure()
+		}
+		if err := b.Allow(); err != nil {
+			t.Errorf("Allow() on disabled breaker = %v", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"tg-bot/models"
+	"time"
+)
+
+// CacheConfig настройки кэширования ответов API.
+// Нулевой TTL отключает кэш для соответствующего запроса
+type CacheConfig struct {
+	CityTTL    time.Duration
+	NearbyTTL  time.Duration
+	DetailTTL  time.Duration
+	MaxEntries int
+}
+
+// DefaultCacheConfig настройки кэша по умолчанию
+var DefaultCacheConfig = CacheConfig{
+	CityTTL:    10 * time.Minute,
+	NearbyTTL:  5 * time.Minute,
+	DetailTTL:  30 * time.Minute,
+	MaxEntries: 1000,
+}
+
+// CachedService кэширует ответы другого Service в памяти.
+// Одновременные одинаковые запросы выполняются один раз; отмена
+// контекста одного вызывающего не прерывает запрос для остальных
+type CachedService struct {
+	next Service
+
+	cities  *lru[string, []models.Attraction]
+	nearby  *lru[string, []models.Attraction]
+	details *lru[int, models.AttractionDetail]
+
+	cityFlight   flightGroup[string, []models.Attraction]
+	nearbyFlight flightGroup[string, []models.Attraction]
+	detailFlight flightGroup[int, models.AttractionDetail]
+}
+
+// NewCachedService оборачивает next кэшем
+func NewCachedService(next Service, cfg CacheConfig) *CachedService {
+	return &CachedService{
+		next:    next,
+		cities:  newLRU[string, []models.Attraction](cfg.MaxEntries, cfg.CityTTL),
+		nearby:  newLRU[string, []models.Attraction](cfg.MaxEntries, cfg.NearbyTTL),
+		details: newLRU[int, models.AttractionDetail](cfg.MaxEntries, cfg.DetailTTL),
+	}
+}
+
+// AttractionsByCity возвращает достопримечательности города из кэша или API
+func (s *CachedService) AttractionsByCity(ctx context.Context, city string) ([]models.Attraction, error) {
+	key := cityKey(city)
+	if cached, ok := s.cities.get(key); ok {
+		return copyAttractions(cached), nil
+	}
+
+	// Результат общий для всех ожидающих, поэтому наружу отдаем копию
+	attractions, err := s.cityFlight.do(ctx, key, func(ctx context.Context) ([]models.Attraction, error) {
+		attractions, err := s.next.AttractionsByCity(ctx, city)
+		if err == nil {
+			s.cities.set(key, attractions)
+		}
+		return attractions, err
+	})
+	return copyAttractions(attractions), err
+}
+
+// AttractionsByLocation возвращает достопримечательности вокруг точки из кэша или API
+func (s *CachedService) AttractionsByLocation(ctx context.Context, lat, lon float64, radius float64) ([]models.Attraction, error) {
+	key := locationKey(lat, lon, radius)
+	if cached, ok := s.nearby.get(key); ok {
+		return copyAttractions(cached), nil
+	}
+
+	attractions, err := s.nearbyFlight.do(ctx, key, func(ctx context.Context) ([]models.Attraction, error) {
+		attractions, err := s.next.AttractionsByLocation(ctx, lat, lon, radius)
+		if err == nil {
+			s.nearby.set(key, attractions)
+		}
+		return attractions, err
+	})
+	return copyAttractions(attractions), err
+}
+
+// AttractionDetail возвращает детали достопримечательности из кэша или API
+func (s *CachedService) AttractionDetail(ctx context.Context, id int) (models.AttractionDetail, error) {
+	if cached, ok := s.details.get(id); ok {
+		return copyDetail(cached), nil
+	}
+
+	detail, err := s.detailFlight.do(ctx, id, func(ctx context.Context) (models.AttractionDetail, error) {
+		detail, err := s.next.AttractionDetail(ctx, id)
+		if err == nil {
+			s.details.set(id, detail)
+		}
+		return detail, err
+	})
+	return copyDetail(detail), err
+}
+
+// cityKey приводит название города к единому виду:
+// "  ярославль " и "Ярославль" дают один ключ
+func cityKey(city string) string {
+	city = strings.ToLower(strings.Join(strings.Fields(city), " "))
+	return strings.ReplaceAll(city, "ё", "е")
+}
+
+// locationKey округляет координаты примерно до 100 метров,
+// чтобы соседние точки попадали в одну запись
+func locationKey(lat, lon, radius float64) string {
+	return fmt.Sprintf("%.3f,%.3f,%g", lat, lon, radius)
+}
+
+func copyAttractions(attractions []models.Attraction) []models.Attraction {
+	if attractions == nil {
+		return nil
+	}
+	return append([]models.Attraction(nil), attractions...)
+}
+
+func copyDetail(detail models.AttractionDetail) models.AttractionDetail {
+	detail.Photos = append([]string(nil), detail.Photos...)
+	return detail
+}
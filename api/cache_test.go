@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"tg-bot/models"
+	"time"
+)
+
+// slowService отвечает после release и считает обращения
+type slowService struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (s *slowService) AttractionsByCity(ctx context.Context, city string) ([]models.Attraction, error) {
+	atomic.AddInt32(&s.calls, 1)
+	select {
+	case <-s.release:
+		return []models.Attraction{{ID: 1, Name: city}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *slowService) AttractionsByLocation(ctx context.Context, lat, lon float64, radius float64) ([]models.Attraction, error) {
+	return nil, nil
+}
+
+func (s *slowService) AttractionDetail(ctx context.Context, id int) (models.AttractionDetail, error) {
+	return models.AttractionDetail{}, nil
+}
+
+func TestCachedServiceCoalescing(t *testing.T) {
+	next := &slowService{release: make(chan struct{})}
+	cache := NewCachedService(next, DefaultCacheConfig)
+
+	// Первый вызывающий уходит, не дождавшись ответа
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.AttractionsByCity(firstCtx, "Ярославль")
+		firstErr <- err
+	}()
+
+	second := make(chan []models.Attraction, 1)
+	go func() {
+		// Ждем, пока первый запрос уйдет в API
+		for atomic.LoadInt32(&next.calls) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		attractions, err := cache.AttractionsByCity(context.Background(), "ярославль")
+		if err != nil {
+			t.Errorf("second caller error = %v", err)
+		}
+		second <- attractions
+	}()
+
+	for atomic.LoadInt32(&next.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller error = %v, want context.Canceled", err)
+	}
+
+	close(next.release)
+	if got := <-second; len(got) != 1 {
+		t.Fatalf("second caller got %d attractions, want 1", len(got))
+	}
+	if calls := atomic.LoadInt32(&next.calls); calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+
+	// Ответ попал в кэш
+	if _, err := cache.AttractionsByCity(context.Background(), "Ярославль"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := atomic.LoadInt32(&next.calls); calls != 1 {
+		t.Errorf("upstream called %d times after cache hit, want 1", calls)
+	}
+}
+
+func TestCachedServiceWaiterTimeout(t *testing.T) {
+	next := &slowService{release: make(chan struct{})}
+	defer close(next.release)
+	cache := NewCachedService(next, DefaultCacheConfig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := cache.AttractionsByCity(ctx, "Кострома"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
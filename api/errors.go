@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound запрошенный объект не существует (404)
+	ErrNotFound = errors.New("api: not found")
+	// ErrRateLimited API ограничило частоту запросов (429)
+	ErrRateLimited = errors.New("api: rate limited")
+	// ErrUpstream API вернуло ошибку или ответ, который не удалось разобрать
+	ErrUpstream = errors.New("api: upstream error")
+)
+
+// maxSnippetLength сколько байт тела ошибки сохраняется в StatusError
+const maxSnippetLength = 512
+
+// StatusError ответ API с кодом, отличным от 2xx.
+// Сравнивается через errors.Is с ErrNotFound, ErrRateLimited или ErrUpstream
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter задержка из заголовка Retry-After, если он был
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("api: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("api: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	default:
+		return ErrUpstream
+	}
+}
+
+// newStatusError создает ошибку с обрезанным до maxSnippetLength телом ответа
+func newStatusError(statusCode int, body []byte) *StatusError {
+	if len(body) > maxSnippetLength {
+		body = body[:maxSnippetLength]
+	}
+	// cleanUTF8 заодно убирает символ, обрезанный на границе
+	return &StatusError{
+		StatusCode: statusCode,
+		Body:       strings.TrimSpace(cleanUTF8(string(body))),
+	}
+}
+
+// decodeError оборачивает ошибку разбора JSON так, чтобы она сравнивалась с ErrUpstream
+func decodeError(err error) error {
+	return fmt.Errorf("%w: decode response: %v", ErrUpstream, err)
+}
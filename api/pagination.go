@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"tg-bot/models"
+)
+
+// Pager лениво проходит по страницам выдачи в формате DRF,
+// следуя ссылкам next из ответа
+type Pager struct {
+	client  *Client
+	method  string
+	body    []byte
+	next    string
+	visited map[string]bool
+	count   int
+	// truncated выдача оборвана ограничением maxPages
+	truncated bool
+}
+
+// page объединяет форматы ответов API: список может прийти
+// как в поле attractions, так и в поле results
+type page struct {
+	models.APIResponse
+	Attractions []models.Attraction `json:"attractions"`
+}
+
+// CityPager создает итератор по страницам достопримечательностей города
+func (c *Client) CityPager(city string) (*Pager, error) {
+	// Создаем запрос с городом
+	cityReq := models.CityRequest{
+		City: city,
+	}
+
+	// Конвертируем в JSON
+	jsonData, err := json.Marshal(cityReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newPager(http.MethodPost, fmt.Sprintf("%s/cities/", c.baseURL), jsonData), nil
+}
+
+// LocationPager создает итератор по страницам достопримечательностей вокруг точки
+func (c *Client) LocationPager(lat, lon float64, radius float64) *Pager {
+	// Формируем URL с query-параметрами
+	url := fmt.Sprintf("%s/map/attractions/?lat=%f&lng=%f&radius=%f",
+		c.baseURL, lat, lon, radius)
+
+	return c.newPager(http.MethodGet, url, nil)
+}
+
+func (c *Client) newPager(method, url string, body []byte) *Pager {
+	return &Pager{
+		client:  c,
+		method:  method,
+		body:    body,
+		next:    url,
+		visited: make(map[string]bool),
+	}
+}
+
+// Done сообщает, что страниц больше нет
+func (p *Pager) Done() bool {
+	return p.next == ""
+}
+
+// Truncated сообщает, что All остановился на ограничении maxPages,
+// хотя у API были еще страницы
+func (p *Pager) Truncated() bool {
+	return p.truncated
+}
+
+// Count общее число результатов по данным API, если оно известно
+func (p *Pager) Count() int {
+	return p.count
+}
+
+// Next загружает следующую страницу. После последней страницы
+// возвращает nil без ошибки
+func (p *Pager) Next(ctx context.Context) ([]models.Attraction, error) {
+	if p.Done() {
+		return nil, nil
+	}
+
+	current := p.next
+	p.visited[current] = true
+
+	body, err := p.client.do(ctx, p.method, current, p.body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Парсим ответ
+	var resp page
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, decodeError(err)
+	}
+	if resp.Count > 0 {
+		p.count = resp.Count
+	}
+
+	p.next = ""
+	if resp.Next != "" {
+		next, err := resolveNext(current, resp.Next)
+		if err != nil {
+			return nil, err
+		}
+		// Защищаемся от зацикленных ссылок
+		if !p.visited[next] {
+			p.next = next
+		}
+	}
+
+	if resp.Attractions != nil {
+		return resp.Attractions, nil
+	}
+	return resp.Results, nil
+}
+
+// All загружает страницы подряд, пока они не закончатся или не будет
+// достигнуто ограничение maxPages. 0 снимает ограничение.
+// Если выдача оборвана ограничением, это пишется в лог и отмечается в Truncated
+func (p *Pager) All(ctx context.Context, maxPages int) ([]models.Attraction, error) {
+	var all []models.Attraction
+	for pages := 0; !p.Done() && (maxPages <= 0 || pages < maxPages); pages++ {
+		items, err := p.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+	if !p.Done() {
+		p.truncated = true
+		log.Printf("Выдача обрезана ограничением в %d страниц: загружено %d из %d, следующая страница %s",
+			maxPages, len(all), p.count, p.next)
+	}
+	return all, nil
+}
+
+// resolveNext приводит ссылку next к абсолютному адресу
+func resolveNext(current, next string) (string, error) {
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
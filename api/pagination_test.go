@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pagesServer отдает pages страниц по одной достопримечательности.
+// next задает ссылку со страницы n на следующую
+func pagesServer(t *testing.T, pages int, next func(n int) string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &n)
+		link := ""
+		if n < pages {
+			link = next(n)
+		}
+		fmt.Fprintf(w, `{"count": %d, "next": %q, "results": [{"id": %d}]}`, pages, link, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPagerAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		pages         int
+		maxPages      int
+		next          func(n int) string
+		wantIDs       int
+		wantTruncated bool
+	}{
+		{
+			name:     "relative next links",
+			pages:    3,
+			maxPages: 10,
+			next:     func(n int) string { return fmt.Sprintf("?page=%d", n+1) },
+			wantIDs:  3,
+		},
+		{
+			name:          "cap reached",
+			pages:         5,
+			maxPages:      2,
+			next:          func(n int) string { return fmt.Sprintf("?page=%d", n+1) },
+			wantIDs:       2,
+			wantTruncated: true,
+		},
+		{
+			name:     "no cap",
+			pages:    5,
+			maxPages: 0,
+			next:     func(n int) string { return fmt.Sprintf("?page=%d", n+1) },
+			wantIDs:  5,
+		},
+		{
+			name:     "looping next link",
+			pages:    5,
+			maxPages: 10,
+			next:     func(n int) string { return "?page=1" },
+			wantIDs:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := pagesServer(t, tt.pages, tt.next)
+			client := NewClient(WithBaseURL(srv.URL), WithRetry(0, 0))
+
+			pager := client.LocationPager(57.6, 39.8, 0.01)
+			got, err := pager.All(context.Background(), tt.maxPages)
+			if err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+			if len(got) != tt.wantIDs {
+				t.Errorf("All() returned %d attractions, want %d", len(got), tt.wantIDs)
+			}
+			if pager.Truncated() != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", pager.Truncated(), tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter верхняя граница ожидания по заголовку Retry-After
+const maxRetryAfter = 30 * time.Second
+
+// jitter источник случайных задержек. Глобальный math/rand при go 1.18
+// в go.mod не инициализируется случайным seed
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// isTransient решает, вызвана ли ошибка временной проблемой API: сбой сети,
+// таймаут попытки, 5xx или 429. Такие запросы повторяются и учитываются
+// предохранителем. ctx контекст вызывающего кода: если он отменен,
+// ошибка ничего не говорит о состоянии API
+func isTransient(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return !errors.Is(err, ErrUpstream) && !errors.Is(err, ErrCircuitOpen)
+}
+
+// backoff вычисляет задержку перед попыткой attempt (с нуля):
+// экспоненциальный рост от base до limit со случайным разбросом.
+// Если API прислало Retry-After, ждем не меньше указанного
+func backoff(attempt int, base, limit time.Duration, err error) time.Duration {
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > limit {
+		delay = limit
+	}
+
+	jitter.Lock()
+	delay = delay/2 + time.Duration(jitter.Int63n(int64(delay/2)+1))
+	jitter.Unlock()
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+		if delay > maxRetryAfter {
+			delay = maxRetryAfter
+		}
+	}
+	return delay
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в секундах или в виде даты
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
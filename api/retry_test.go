@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	const (
+		base  = 100 * time.Millisecond
+		limit = time.Second
+	)
+
+	tests := []struct {
+		name     string
+		attempt  int
+		err      error
+		min, max time.Duration
+	}{
+		{name: "first attempt", attempt: 0, min: base / 2, max: base},
+		{name: "exponential growth", attempt: 2, min: 2 * base, max: 4 * base},
+		{name: "capped by limit", attempt: 10, min: limit / 2, max: limit},
+		{name: "shift overflow", attempt: 100, min: limit / 2, max: limit},
+		{
+			name:    "retry after",
+			attempt: 0,
+			err:     &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second},
+			min:     5 * time.Second,
+			max:     5 * time.Second,
+		},
+		{
+			name:    "retry after capped",
+			attempt: 0,
+			err:     &StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour},
+			min:     maxRetryAfter,
+			max:     maxRetryAfter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := backoff(tt.attempt, base, limit, tt.err)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("backoff(%d) = %v, want in [%v, %v]", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "nil", ctx: context.Background(), err: nil},
+		{name: "network", ctx: context.Background(), err: errors.New("connection reset"), want: true},
+		{name: "server error", ctx: context.Background(), err: &StatusError{StatusCode: 502}, want: true},
+		{name: "rate limited", ctx: context.Background(), err: &StatusError{StatusCode: 429}, want: true},
+		{name: "not found", ctx: context.Background(), err: &StatusError{StatusCode: 404}},
+		{name: "circuit open", ctx: context.Background(), err: ErrCircuitOpen},
+		{name: "caller cancelled", ctx: cancelled, err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
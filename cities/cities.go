@@ -0,0 +1,92 @@
+// Package cities узнает названия городов, написанные с ошибками,
+// в латинице или разговорными словами, и подсказывает похожие
+package cities
+
+import "sort"
+
+// index ключ названия или псевдонима -> индекс города в known
+var index = make(map[string]int)
+
+func init() {
+	for i, name := range known {
+		index[key(name)] = i
+	}
+	for alias, name := range aliases {
+		i, ok := Index(name)
+		if !ok {
+			panic("cities: alias " + alias + " points to unknown city " + name)
+		}
+		index[key(alias)] = i
+	}
+}
+
+// Normalize возвращает название известного города для запроса вроде
+// «г. Москва», «Moskva» или «Питер». Если город неизвестен, возвращает
+// запрос без слова «город» в начале и false
+func Normalize(query string) (string, bool) {
+	if i, ok := index[key(query)]; ok {
+		return known[i], true
+	}
+	return stripPrefix(query), false
+}
+
+// Index индекс известного города по его точному названию
+func Index(name string) (int, bool) {
+	for i, city := range known {
+		if city == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Name название известного города по индексу
+func Name(i int) (string, bool) {
+	if i < 0 || i >= len(known) {
+		return "", false
+	}
+	return known[i], true
+}
+
+// Suggest возвращает до n известных городов, ближайших к запросу
+// по редакционному расстоянию, от самого похожего
+func Suggest(query string, n int) []string {
+	target := key(query)
+	if target == "" {
+		return nil
+	}
+	// Чем длиннее запрос, тем больше опечаток допускается
+	limit := len([]rune(target))/3 + 1
+
+	best := make(map[int]int)
+	for k, i := range index {
+		d := min(levenshtein(target, k), levenshtein(sortWords(target), sortWords(k)))
+		if d > limit {
+			continue
+		}
+		if prev, ok := best[i]; !ok || d < prev {
+			best[i] = d
+		}
+	}
+
+	suggestions := make([]int, 0, len(best))
+	for i := range best {
+		suggestions = append(suggestions, i)
+	}
+	sort.Slice(suggestions, func(a, b int) bool {
+		da, db := best[suggestions[a]], best[suggestions[b]]
+		if da != db {
+			return da < db
+		}
+		return suggestions[a] < suggestions[b]
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+
+	names := make([]string, len(suggestions))
+	for i, city := range suggestions {
+		names[i] = known[city]
+	}
+	return names
+}
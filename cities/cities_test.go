@@ -0,0 +1,91 @@
+package cities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{"Ярославль", "Ярославль", true},
+		{"  ярославль ", "Ярославль", true},
+		{"г. Москва", "Москва", true},
+		{"город Кострома", "Кострома", true},
+		{"Moskva", "Москва", true},
+		{"Yaroslavl", "Ярославль", true},
+		{"Jaroslavl", "Ярославль", true},
+		{"Питер", "Санкт-Петербург", true},
+		{"санкт петербург", "Санкт-Петербург", true},
+		{"Санкт-Петербург", "Санкт-Петербург", true},
+		{"плес", "Плёс", true},
+		{"г. Неизвестный", "Неизвестный", false},
+		{"Город", "Город", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Normalize(tt.query)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.query, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		n     int
+		want  []string
+	}{
+		{name: "typo", query: "Ярославь", n: 3, want: []string{"Ярославль"}},
+		{name: "latin typo", query: "Kostrama", n: 3, want: []string{"Кострома"}},
+		{name: "word order", query: "Новгород Великий", n: 1, want: []string{"Великий Новгород"}},
+		{name: "limit", query: "Ростов", n: 1, want: []string{"Ростов"}},
+		{name: "nothing similar", query: "Абвгдежзик", n: 3, want: []string{}},
+		{name: "empty", query: " ", n: 3, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Suggest(tt.query, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %q, want %q", tt.query, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameIndex(t *testing.T) {
+	for i, city := range known {
+		if got, ok := Index(city); !ok || got != i {
+			t.Errorf("Index(%q) = %d, %v, want %d", city, got, ok, i)
+		}
+		if got, ok := Name(i); !ok || got != city {
+			t.Errorf("Name(%d) = %q, %v, want %q", i, got, ok, city)
+		}
+	}
+	if _, ok := Name(len(known)); ok {
+		t.Error("Name() accepts index past the end")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"москва", "масква", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
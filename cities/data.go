@@ -0,0 +1,95 @@
+package cities
+
+// known города, которые бот узнает при опечатках и в латинице.
+// Индекс города в списке передается в кнопках подсказок, поэтому
+// новые города добавляются только в конец
+var known = []string{
+	"Ярославль",
+	"Ростов",
+	"Переславль-Залесский",
+	"Углич",
+	"Рыбинск",
+	"Тутаев",
+	"Мышкин",
+	"Пошехонье",
+	"Данилов",
+	"Гаврилов-Ям",
+	"Любим",
+	"Кострома",
+	"Иваново",
+	"Суздаль",
+	"Владимир",
+	"Сергиев Посад",
+	"Плёс",
+	"Александров",
+	"Вологда",
+	"Кириллов",
+	"Москва",
+	"Санкт-Петербург",
+	"Великий Новгород",
+	"Псков",
+	"Тверь",
+	"Тула",
+	"Рязань",
+	"Калуга",
+	"Смоленск",
+	"Нижний Новгород",
+	"Казань",
+	"Самара",
+	"Саратов",
+	"Волгоград",
+	"Астрахань",
+	"Воронеж",
+	"Калининград",
+	"Архангельск",
+	"Мурманск",
+	"Петрозаводск",
+	"Краснодар",
+	"Ростов-на-Дону",
+	"Сочи",
+	"Кисловодск",
+	"Пятигорск",
+	"Севастополь",
+	"Ялта",
+	"Екатеринбург",
+	"Пермь",
+	"Уфа",
+	"Челябинск",
+	"Тобольск",
+	"Омск",
+	"Новосибирск",
+	"Томск",
+	"Красноярск",
+	"Иркутск",
+	"Хабаровск",
+	"Владивосток",
+}
+
+// aliases разговорные, старые и английские названия городов
+var aliases = map[string]string{
+	"ярик":             "Ярославль",
+	"ростов великий":   "Ростов",
+	"переславль":       "Переславль-Залесский",
+	"загорск":          "Сергиев Посад",
+	"мск":              "Москва",
+	"moscow":           "Москва",
+	"питер":            "Санкт-Петербург",
+	"спб":              "Санкт-Петербург",
+	"петербург":        "Санкт-Петербург",
+	"ленинград":        "Санкт-Петербург",
+	"saint petersburg": "Санкт-Петербург",
+	"st petersburg":    "Санкт-Петербург",
+	"spb":              "Санкт-Петербург",
+	"новгород":         "Великий Новгород",
+	"нижний":           "Нижний Новгород",
+	"нн":               "Нижний Новгород",
+	"горький":          "Нижний Новгород",
+	"кенигсберг":       "Калининград",
+	"кениг":            "Калининград",
+	"екб":              "Екатеринбург",
+	"ебург":            "Екатеринбург",
+	"свердловск":       "Екатеринбург",
+	"новосиб":          "Новосибирск",
+	"нск":              "Новосибирск",
+	"сталинград":       "Волгоград",
+}
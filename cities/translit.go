@@ -0,0 +1,104 @@
+package cities
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// latin транслитерация кириллицы, близкая к той, которой пишут названия
+// городов латиницей. Мягкий и твердый знаки опускаются
+var latin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// latinVariants буквы, которые в латинице пишут по-разному
+var latinVariants = map[rune]string{
+	'j': "y", 'w': "v", 'x': "ks", '\'': "",
+}
+
+// cityPrefixes слова, которыми часто начинают название города
+var cityPrefixes = map[string]bool{
+	"г": true, "гор": true, "город": true, "city": true,
+}
+
+// clean приводит запрос к словам в нижнем регистре без знаков препинания
+// и слова «город» в начале: «г. Санкт-Петербург» -> «санкт петербург»
+func clean(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > 1 && cityPrefixes[words[0]] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// stripPrefix убирает слово «город» в начале запроса, сохраняя остальное как есть
+func stripPrefix(query string) string {
+	words := strings.Fields(query)
+	if len(words) > 1 && cityPrefixes[strings.TrimRight(strings.ToLower(words[0]), ".")] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// key ключ для сравнения названий: очищенный запрос в латинице,
+// так что «Москва», «москва» и «Moskva» дают одно и то же
+func key(query string) string {
+	var builder strings.Builder
+	for _, r := range clean(query) {
+		if s, ok := latin[r]; ok {
+			builder.WriteString(s)
+		} else if s, ok := latinVariants[r]; ok {
+			builder.WriteString(s)
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// sortWords ключ с отсортированными словами, чтобы «Новгород Великий»
+// был похож на «Великий Новгород»
+func sortWords(key string) string {
+	words := strings.Fields(key)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// levenshtein редакционное расстояние между строками в рунах
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
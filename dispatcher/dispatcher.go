@@ -0,0 +1,182 @@
+package dispatcher
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Dispatcher обрабатывает обновления фиксированным числом воркеров.
+// У каждого чата своя очередь, и обновления одного чата выполняются строго
+// по очереди. Воркеры берут чаты с ожидающими обновлениями по кругу,
+// поэтому медленный обработчик одного чата не задерживает остальные
+type Dispatcher struct {
+	handle    func(tgbotapi.Update)
+	queueSize int
+	limit     int
+	wg        sync.WaitGroup
+
+	mu sync.Mutex
+	// work будит воркеров, когда появляется чат в ready или Dispatcher закрыт
+	work *sync.Cond
+	// space будит Dispatch, когда освобождается место в очередях
+	space *sync.Cond
+	// chats очереди чатов, у которых есть ожидающие или выполняемое обновление
+	chats map[int64]*chatQueue
+	// ready чаты, ожидающие свободного воркера
+	ready   []*chatQueue
+	pending int
+	closed  bool
+}
+
+// chatQueue очередь обновлений одного чата. Пока воркер выполняет обновление
+// чата, очередь не стоит в ready, и следующее обновление ждет
+type chatQueue struct {
+	key     int64
+	updates []tgbotapi.Update
+}
+
+// New запускает workers воркеров. queueSize ограничивает число ожидающих
+// обновлений одного чата, всего в очередях может быть workers*queueSize.
+// handle вызывается для каждого обновления
+func New(workers, queueSize int, handle func(tgbotapi.Update)) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &Dispatcher{
+		handle:    handle,
+		queueSize: queueSize,
+		limit:     workers * queueSize,
+		chats:     make(map[int64]*chatQueue),
+	}
+	d.work = sync.NewCond(&d.mu)
+	d.space = sync.NewCond(&d.mu)
+
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Dispatch ставит обновление в очередь его чата. Если очередь чата
+// заполнена, обновление отбрасывается, чтобы один чат не занял все место.
+// Если заполнены очереди всех чатов вместе, ждет. После Shutdown
+// обновления отбрасываются
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) {
+	key := chatKey(update)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for !d.closed && d.pending >= d.limit {
+		d.space.Wait()
+	}
+	if d.closed {
+		log.Printf("Dispatcher is closed, dropping update %d", update.UpdateID)
+		return
+	}
+
+	queue, ok := d.chats[key]
+	if !ok {
+		queue = &chatQueue{key: key}
+		d.chats[key] = queue
+		d.ready = append(d.ready, queue)
+		d.work.Signal()
+	}
+	if len(queue.updates) >= d.queueSize {
+		log.Printf("Queue of chat %d is full, dropping update %d", key, update.UpdateID)
+		return
+	}
+	queue.updates = append(queue.updates, update)
+	d.pending++
+}
+
+// Shutdown перестает принимать обновления и ждет, пока воркеры
+// обработают уже поставленные в очередь. Возвращает ctx.Err(),
+// если обработка не закончилась до отмены ctx
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.work.Broadcast()
+	d.space.Broadcast()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		for len(d.ready) == 0 && !d.closed {
+			d.work.Wait()
+		}
+		if len(d.ready) == 0 {
+			// Закрыт, и ожидающих обновлений не осталось
+			return
+		}
+
+		queue := d.ready[0]
+		d.ready = d.ready[1:]
+		update := queue.updates[0]
+		queue.updates = queue.updates[1:]
+		d.pending--
+		d.space.Signal()
+
+		d.mu.Unlock()
+		d.process(update)
+		d.mu.Lock()
+
+		// Чат встает в конец, чтобы воркеры обходили чаты по кругу
+		if len(queue.updates) > 0 {
+			d.ready = append(d.ready, queue)
+			d.work.Signal()
+		} else {
+			delete(d.chats, queue.key)
+		}
+	}
+}
+
+// process вызывает обработчик, не давая панике остановить воркер
+func (d *Dispatcher) process(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic while handling update %d: %v\n%s", update.UpdateID, r, debug.Stack())
+		}
+	}()
+
+	d.handle(update)
+}
+
+// chatKey определяет, к какому чату или пользователю относится обновление
+func chatKey(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatUpdate обновление с сообщением в чате chatID
+func chatUpdate(id int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message:  &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+	}
+}
+
+func TestDispatchKeepsChatOrder(t *testing.T) {
+	const chats, perChat = 10, 50
+
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+	d := New(4, perChat, func(update tgbotapi.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.UpdateID)
+	})
+
+	id := 0
+	for i := 0; i < perChat; i++ {
+		for chat := int64(-chats / 2); chat < chats/2; chat++ {
+			id++
+			d.Dispatch(chatUpdate(id, chat))
+		}
+	}
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != chats {
+		t.Fatalf("handled updates of %d chats, want %d", len(seen), chats)
+	}
+	for chat, ids := range seen {
+		if len(ids) != perChat {
+			t.Errorf("chat %d: handled %d updates, want %d", chat, len(ids), perChat)
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Errorf("chat %d: update %d handled after %d", chat, ids[i], ids[i-1])
+				break
+			}
+		}
+	}
+}
+
+func TestDispatchRecoversFromPanic(t *testing.T) {
+	var mu sync.Mutex
+	var handled []int
+	d := New(1, 10, func(update tgbotapi.Update) {
+		if update.UpdateID == 1 {
+			panic("boom")
+		}
+		mu.Lock()
+		handled = append(handled, update.UpdateID)
+		mu.Unlock()
+	})
+
+	d.Dispatch(chatUpdate(1, 1))
+	d.Dispatch(chatUpdate(2, 1))
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handled) != 1 || handled[0] != 2 {
+		t.Errorf("handled = %v, want [2]", handled)
+	}
+}
+
+func TestSlowChatDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan int64, 10)
+	d := New(2, 10, func(update tgbotapi.Update) {
+		chatID := update.Message.Chat.ID
+		if chatID == 1 {
+			<-release
+		}
+		handled <- chatID
+	})
+
+	// Первый чат занимает воркер, его следующие обновления ждут в очереди чата
+	for i := 0; i < 5; i++ {
+		d.Dispatch(chatUpdate(i, 1))
+	}
+	for i := 0; i < 5; i++ {
+		d.Dispatch(chatUpdate(10+i, int64(2+i)))
+	}
+
+	for i := 0; i < 5; i++ {
+		select {
+		case chatID := <-handled:
+			if chatID == 1 {
+				t.Fatal("slow chat update handled before release")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("updates of other chats wait for the slow chat")
+		}
+	}
+
+	close(release)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 5 {
+		t.Errorf("handled %d updates of the slow chat, want 5", len(handled))
+	}
+}
+
+func TestDispatchDropsWhenChatQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var mu sync.Mutex
+	var handled []int
+	d := New(4, 2, func(update tgbotapi.Update) {
+		if update.UpdateID == 0 {
+			started <- struct{}{}
+			<-release
+		}
+		mu.Lock()
+		handled = append(handled, update.UpdateID)
+		mu.Unlock()
+	})
+
+	d.Dispatch(chatUpdate(0, 1))
+	<-started
+	for i := 1; i <= 4; i++ {
+		d.Dispatch(chatUpdate(i, 1))
+	}
+	close(release)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{0, 1, 2}
+	if len(handled) != len(want) {
+		t.Fatalf("handled = %v, want %v", handled, want)
+	}
+	for i := range want {
+		if handled[i] != want[i] {
+			t.Fatalf("handled = %v, want %v", handled, want)
+		}
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	var mu sync.Mutex
+	handled := 0
+	d := New(2, 100, func(tgbotapi.Update) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+
+	for i := 0; i < 50; i++ {
+		d.Dispatch(chatUpdate(i, int64(i%3)))
+	}
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 50 {
+		t.Errorf("handled %d updates before Shutdown returned, want 50", handled)
+	}
+
+	// После остановки обновления отбрасываются
+	d.Dispatch(chatUpdate(100, 1))
+	if handled != 50 {
+		t.Errorf("update dispatched after Shutdown was handled")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	d := New(1, 10, func(tgbotapi.Update) {
+		close(started)
+		<-release
+	})
+
+	d.Dispatch(chatUpdate(1, 1))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,80 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadius средний радиус Земли в метрах
+const earthRadius = 6371000.0
+
+// WalkingSpeed средняя скорость пешехода, метров в минуту (около 5 км/ч)
+const WalkingSpeed = 5000.0 / 60
+
+// Distance расстояние по поверхности Земли между двумя точками в метрах
+// (формула гаверсинусов)
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := radians(lat1)
+	phi2 := radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Bearing начальный азимут от первой точки на вторую в градусах [0, 360)
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := radians(lat1)
+	phi2 := radians(lat2)
+	dLambda := radians(lon2 - lon1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// compassPoints стороны света с шагом 45 градусов, начиная с севера
+var compassPoints = []string{"С", "СВ", "В", "ЮВ", "Ю", "ЮЗ", "З", "СЗ"}
+
+// compassArrows стрелки для тех же направлений
+var compassArrows = []string{"⬆️", "↗️", "➡️", "↘️", "⬇️", "↙️", "⬅️", "↖️"}
+
+// Compass переводит азимут в сторону света и стрелку
+func Compass(bearing float64) (point, arrow string) {
+	i := int(math.Round(math.Mod(bearing+360, 360)/45)) % len(compassPoints)
+	return compassPoints[i], compassArrows[i]
+}
+
+// WalkingMinutes время пешком на расстояние meters, округленное вверх до минуты
+func WalkingMinutes(meters float64) int {
+	return int(math.Ceil(meters / WalkingSpeed))
+}
+
+// FormatDistance выводит расстояние в метрах или километрах
+func FormatDistance(meters float64) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%.0f м", math.Round(meters/10)*10)
+	}
+	return fmt.Sprintf("%.1f км", meters/1000)
+}
+
+// FormatDuration выводит время в минутах или часах и минутах
+func FormatDuration(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,100 @@
+package geo
+
+// Point точка на местности
+type Point struct {
+	Lat, Lon float64
+}
+
+// distance расстояние между двумя точками в метрах
+func (p Point) distance(q Point) float64 {
+	return Distance(p.Lat, p.Lon, q.Lat, q.Lon)
+}
+
+// Предел проходов 2-opt: для маршрута из десятка точек улучшения
+// заканчиваются намного раньше
+const maxImprovePasses = 100
+
+// OrderRoute подбирает порядок обхода points пешком из start:
+// сначала жадно идет к ближайшей непосещенной точке, затем улучшает путь
+// перестановками 2-opt. Возвращает индексы points в порядке обхода.
+// Путь незамкнутый: в start возвращаться не нужно
+func OrderRoute(start Point, points []Point) []int {
+	order := nearestNeighbour(start, points)
+	improve(start, points, order)
+	return order
+}
+
+// RouteLength длина пути из start через points в порядке order, в метрах
+func RouteLength(start Point, points []Point, order []int) float64 {
+	length := 0.0
+	prev := start
+	for _, i := range order {
+		length += prev.distance(points[i])
+		prev = points[i]
+	}
+	return length
+}
+
+// nearestNeighbour строит путь, каждый раз переходя к ближайшей непосещенной точке
+func nearestNeighbour(start Point, points []Point) []int {
+	order := make([]int, 0, len(points))
+	visited := make([]bool, len(points))
+	current := start
+	for len(order) < len(points) {
+		next := -1
+		for i, p := range points {
+			if visited[i] {
+				continue
+			}
+			if next < 0 || current.distance(p) < current.distance(points[next]) {
+				next = i
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = points[next]
+	}
+	return order
+}
+
+// improve разворачивает участки пути, пока это его сокращает (2-opt).
+// Первая точка пути — start, она остается на месте
+func improve(start Point, points []Point, order []int) {
+	at := func(i int) Point {
+		if i == 0 {
+			return start
+		}
+		return points[order[i-1]]
+	}
+	// Индексы пути: 0 — start, 1..n — точки в порядке order
+	n := len(order)
+
+	for pass := 0; pass < maxImprovePasses; pass++ {
+		improved := false
+		for i := 1; i < n; i++ {
+			for j := i + 1; j <= n; j++ {
+				// Разворот участка i..j заменяет ребра (i-1, i) и (j, j+1)
+				// на (i-1, j) и (i, j+1). У последней точки ребра дальше нет
+				before := at(i - 1).distance(at(i))
+				after := at(i - 1).distance(at(j))
+				if j < n {
+					before += at(j).distance(at(j + 1))
+					after += at(i).distance(at(j + 1))
+				}
+				if after < before-1e-6 {
+					reverse(order[i-1 : j])
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+func reverse(order []int) {
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+}
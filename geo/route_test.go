@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+// grid точка в Ярославле, сдвинутая на тысячные доли градуса
+func grid(lat, lon float64) Point {
+	return Point{Lat: 57.6 + lat/1000, Lon: 39.8 + lon/1000}
+}
+
+func TestOrderRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  Point
+		points []Point
+		want   []int
+	}{
+		{name: "no points", start: grid(0, 0), points: nil, want: []int{}},
+		{name: "single point", start: grid(0, 0), points: []Point{grid(0, 5)}, want: []int{0}},
+		{
+			name:   "points on a line",
+			start:  grid(0, 0),
+			points: []Point{grid(0, 3), grid(0, 1), grid(0, 4), grid(0, 2)},
+			want:   []int{1, 3, 0, 2},
+		},
+		{
+			// Жадный обход дает 0, 2, 3, 1, разворот участка сокращает путь
+			name:   "improved by 2-opt",
+			start:  grid(0, 0),
+			points: []Point{grid(1, 2), grid(4, 2), grid(2, 2), grid(1, 4)},
+			want:   []int{0, 3, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderRoute(tt.start, tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OrderRoute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderRouteNotLongerThanGreedy(t *testing.T) {
+	start := Point{Lat: 57.626, Lon: 39.894}
+	points := []Point{
+		{57.622, 39.888}, {57.629, 39.901}, {57.617, 39.872}, {57.631, 39.878},
+		{57.624, 39.911}, {57.611, 39.893}, {57.636, 39.889}, {57.620, 39.905},
+	}
+
+	order := OrderRoute(start, points)
+
+	seen := make(map[int]bool)
+	for _, i := range order {
+		if i < 0 || i >= len(points) || seen[i] {
+			t.Fatalf("OrderRoute() = %v is not a permutation", order)
+		}
+		seen[i] = true
+	}
+	if len(order) != len(points) {
+		t.Fatalf("OrderRoute() = %v, want %d points", order, len(points))
+	}
+
+	greedy := RouteLength(start, points, nearestNeighbour(start, points))
+	if got := RouteLength(start, points, order); got > greedy {
+		t.Errorf("route length %.0f m is longer than greedy %.0f m", got, greedy)
+	}
+}
@@ -5,4 +5,7 @@ go 1.18
 require (
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
 	github.com/joho/godotenv v1.5.1
+	go.etcd.io/bbolt v1.3.7
 )
+
+require golang.org/x/sys v0.4.0 // indirect
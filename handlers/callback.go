@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Версия формата данных кнопок. Меняется при несовместимых изменениях,
+// чтобы кнопки старого формата не разбирались неправильно
+const callbackVersion = "v1"
+
+// Telegram ограничивает callback_data 64 байтами
+const maxCallbackData = 64
+
+// Действия кнопок. Данные кнопки: v1:<действие>:<searchID>:<аргументы...>
+const (
+	actionPage       = "p" // страница списка, аргумент номер страницы
+	actionAttraction = "a" // карточка, аргумент ID достопримечательности
+)
+
+// Текст для кнопок, поиск которых уже удален из хранилища
+const expiredText = "⌛ Результаты этого поиска устарели. Отправьте город или геолокацию заново."
+
+// callbackPayload разобранные данные кнопки
+type callbackPayload struct {
+	Action   string
+	SearchID string
+	Args     []string
+}
+
+// callbackData собирает данные кнопки. Все, что нужно для ответа на нажатие,
+// передается в самой кнопке, поэтому она не зависит от последнего поиска чата
+func callbackData(action, searchID string, args ...interface{}) string {
+	parts := []string{callbackVersion, action, searchID}
+	for _, arg := range args {
+		parts = append(parts, fmt.Sprint(arg))
+	}
+	data := strings.Join(parts, ":")
+	if len(data) > maxCallbackData {
+		log.Printf("Данные кнопки длиннее %d байт: %q", maxCallbackData, data)
+	}
+	return data
+}
+
+// parseCallback разбирает данные кнопки текущего формата
+func parseCallback(data string) (callbackPayload, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 3 || parts[0] != callbackVersion {
+		return callbackPayload{}, false
+	}
+	return callbackPayload{
+		Action:   parts[1],
+		SearchID: parts[2],
+		Args:     parts[3:],
+	}, true
+}
+
+// intArg возвращает i-й аргумент как число
+func (p callbackPayload) intArg(i int) (int, bool) {
+	if i >= len(p.Args) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(p.Args[i])
+	return n, err == nil
+}
+
+// обрабатывает callback-и от inline кнопок
+func (h *Handler) HandleCallback(update tgbotapi.Update) {
+	query := update.CallbackQuery
+	chatID := query.Message.Chat.ID
+
+	// Кнопки без версии остались от сообщений, отправленных до появления
+	// SearchID. Их поиск хранился только в памяти и уже недоступен
+	payload, ok := parseCallback(query.Data)
+
+	answer := ""
+	if !ok {
+		answer = expiredText
+	} else {
+		switch payload.Action {
+		case actionPage:
+			answer = h.handlePageCallback(query.Message, payload)
+		case actionAttraction:
+			answer = h.handleAttractionCallback(query, payload)
+		case actionFavorite:
+			answer = h.handleFavoriteCallback(query, payload)
+		case actionRoute:
+			answer = h.handleRouteCallback(query, payload)
+		case actionRouteClear:
+			answer = h.handleRouteClearCallback(query)
+		case actionPhotos:
+			answer = h.handlePhotosCallback(chatID, payload)
+		case actionMap:
+			answer = h.handleMapCallback(chatID, payload)
+		case actionMore:
+			answer = h.handleMoreCallback(chatID, payload)
+		case actionSort, actionFilter:
+			answer = h.handleViewCallback(query.Message, payload)
+		case actionSearch:
+			answer = h.handleSearchCallback(query, payload)
+		case actionRadius:
+			answer = h.handleRadiusCallback(query, payload)
+		case actionCity:
+			answer = h.handleCityCallback(query, payload)
+		case actionWider:
+			answer = h.handleWiderCallback(query, payload)
+		default:
+			answer = expiredText
+		}
+	}
+
+	// Отвечаем на callback, чтобы у кнопки пропал индикатор загрузки
+	callback := tgbotapi.NewCallback(query.ID, answer)
+	callback.ShowAlert = answer != ""
+	if _, err := h.bot.Request(callback); err != nil {
+		log.Printf("Ошибка ответа на callback: %v", err)
+	}
+}
+
+// показывает страницу списка на месте сообщения с кнопкой.
+// Возвращает текст для ответа на callback
+func (h *Handler) handlePageCallback(message *tgbotapi.Message, payload callbackPayload) string {
+	chatID := message.Chat.ID
+	page, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+
+	unlock := h.store.Lock(chatID)
+	defer unlock()
+
+	state, exists := h.store.Search(chatID, payload.SearchID)
+	if !exists {
+		return expiredText
+	}
+	h.sendAttractionsPage(chatID, message.MessageID, state, page)
+	return ""
+}
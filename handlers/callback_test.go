@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   callbackPayload
+		wantOK bool
+	}{
+		{
+			name:   "page",
+			data:   "v1:p:abc123:2",
+			want:   callbackPayload{Action: actionPage, SearchID: "abc123", Args: []string{"2"}},
+			wantOK: true,
+		},
+		{
+			name:   "no args",
+			data:   "v1:a:abc123",
+			want:   callbackPayload{Action: actionAttraction, SearchID: "abc123", Args: []string{}},
+			wantOK: true,
+		},
+		{
+			name:   "several args",
+			data:   "v1:x:id:1:2",
+			want:   callbackPayload{Action: "x", SearchID: "id", Args: []string{"1", "2"}},
+			wantOK: true,
+		},
+		{name: "legacy page", data: "page_2"},
+		{name: "legacy attraction", data: "attraction_0"},
+		{name: "unknown version", data: "v2:p:abc123:2"},
+		{name: "too short", data: "v1:p"},
+		{name: "empty", data: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCallback(tt.data)
+			if ok != tt.wantOK {
+				t.Fatalf("parseCallback(%q) ok = %v, want %v", tt.data, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCallback(%q) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackDataRoundTrip(t *testing.T) {
+	data := callbackData(actionPage, "abc123", 3)
+	payload, ok := parseCallback(data)
+	if !ok {
+		t.Fatalf("parseCallback(%q) failed", data)
+	}
+	if n, ok := payload.intArg(0); !ok || n != 3 {
+		t.Errorf("intArg(0) = %d, %v, want 3, true", n, ok)
+	}
+	if _, ok := payload.intArg(1); ok {
+		t.Error("intArg(1) ok for missing argument")
+	}
+}
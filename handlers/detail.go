@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"tg-bot/api"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+	"tg-bot/session"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок карточки
+const (
+	actionPhotos = "ph" // альбом дополнительных фото, аргумент ID достопримечательности
+	actionMap    = "m"  // точка на карте, аргумент ID достопримечательности
+	actionMore   = "d"  // полное описание, аргумент ID достопримечательности
+)
+
+// Сколько символов описания показывается в карточке
+const descriptionPreviewLength = 300
+
+// Telegram ограничивает текст сообщения 4096 символами
+const maxMessageLength = 4096
+
+// Telegram ограничивает подпись к фото 1024 символами
+const maxCaptionLength = 1024
+
+// Сколько фото помещается в один альбом
+const maxMediaGroupSize = 10
+
+// показывает карточку достопримечательности. Возвращает текст для ответа на callback
+func (h *Handler) handleAttractionCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	chatID := query.Message.Chat.ID
+	id, ok := payload.intArg(0)
+	if !ok {
+		return "Ошибка выбора"
+	}
+
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+
+	h.sendAttractionDetail(chatID, detail, h.detailKeyboard(chatID, query.From.ID, payload.SearchID, detail))
+	return ""
+}
+
+// загружает детали достопримечательности. Если не удалось,
+// сообщает об ошибке в чат и возвращает false
+func (h *Handler) loadDetail(chatID int64, id int) (models.AttractionDetail, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	detail, err := h.api.AttractionDetail(ctx, id)
+	cancel()
+	if err == nil {
+		return detail, true
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "")
+	if errors.Is(err, api.ErrNotFound) {
+		msg.Text = "🚫 Эта достопримечательность больше не существует"
+	} else {
+		log.Printf("Ошибка при загрузке деталей: %v", err)
+		msg.Text = apiErrorText(err, " Ошибка при загрузке деталей")
+	}
+	h.bot.Send(msg)
+	return detail, false
+}
+
+// клавиатура карточки для пользователя userID. Кнопка возврата к списку есть,
+// только пока поиск хранится
+func (h *Handler) detailKeyboard(chatID, userID int64, searchID string, detail models.AttractionDetail) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	var mediaRow []tgbotapi.InlineKeyboardButton
+	if len(detail.Photos) > 0 {
+		mediaRow = append(mediaRow,
+			tgbotapi.NewInlineKeyboardButtonData("📸 Ещё фото", callbackData(actionPhotos, "", detail.ID)))
+	}
+	if detail.Latitude != 0 || detail.Longitude != 0 {
+		mediaRow = append(mediaRow,
+			tgbotapi.NewInlineKeyboardButtonData("🗺️ На карте", callbackData(actionMap, "", detail.ID)))
+	}
+	if len(mediaRow) > 0 {
+		rows = append(rows, mediaRow)
+	}
+
+	if htmltext.Length(fullDescription(detail)) > descriptionPreviewLength {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📖 Читать дальше", callbackData(actionMore, "", detail.ID)),
+		))
+	}
+
+	favorite := session.IsFavorite(h.store.Favorites(userID), detail.ID)
+	listRow := tgbotapi.NewInlineKeyboardRow(favoriteButton(searchID, detail.ID, favorite))
+	if detail.Latitude != 0 || detail.Longitude != 0 {
+		inRoute := h.store.Route(userID).HasStop(detail.ID)
+		listRow = append(listRow, routeButton(searchID, detail.ID, inRoute))
+	}
+	rows = append(rows, listRow)
+
+	if state, exists := h.store.Search(chatID, searchID); exists {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Назад к списку",
+				callbackData(actionPage, state.SearchID, state.Page)),
+		))
+	}
+
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// отправляет карточку: главное фото с описанием в подписи или,
+// если фото нет или описание не помещается в подпись, отдельным сообщением
+func (h *Handler) sendAttractionDetail(chatID int64, detail models.AttractionDetail, keyboard tgbotapi.InlineKeyboardMarkup) {
+	text := formatAttractionDetail(detail)
+
+	var markup interface{}
+	if len(keyboard.InlineKeyboard) > 0 {
+		markup = keyboard
+	}
+
+	if detail.MainPhotoURL != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(detail.MainPhotoURL))
+		photo.ParseMode = "HTML"
+
+		fitsCaption := htmltext.VisibleLength(text) <= maxCaptionLength
+		if fitsCaption {
+			photo.Caption = text
+			photo.ReplyMarkup = markup
+		} else {
+			photo.Caption = htmltext.Format("<b>🏛️ %s</b>", htmltext.Truncate(detail.Name, maxCaptionLength-10))
+		}
+
+		if _, err := h.bot.Send(photo); err == nil {
+			if fitsCaption {
+				return
+			}
+		} else {
+			// Telegram не смог загрузить фото, оставляем ссылку в тексте
+			log.Printf("Ошибка отправки фото %s: %v", detail.MainPhotoURL, err)
+			text += htmltext.Format("\n📸 <a href=\"%s\">Фото</a>", detail.MainPhotoURL)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = markup
+	h.bot.Send(msg)
+}
+
+// отправляет дополнительные фото альбомом. Возвращает текст для ответа на callback
+func (h *Handler) handlePhotosCallback(chatID int64, payload callbackPayload) string {
+	id, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+	if len(detail.Photos) == 0 {
+		return "У этой достопримечательности нет других фото"
+	}
+
+	// Альбом из одного фото Telegram не принимает
+	if len(detail.Photos) == 1 {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(detail.Photos[0]))
+		photo.Caption = htmltext.Truncate(detail.Name, maxCaptionLength)
+		if _, err := h.bot.Send(photo); err != nil {
+			log.Printf("Ошибка отправки фото: %v", err)
+			return "Не удалось загрузить фото"
+		}
+		return ""
+	}
+
+	photos := detail.Photos
+	if len(photos) > maxMediaGroupSize {
+		photos = photos[:maxMediaGroupSize]
+	}
+
+	media := make([]interface{}, 0, len(photos))
+	for i, url := range photos {
+		photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileURL(url))
+		if i == 0 {
+			photo.Caption = htmltext.Truncate(detail.Name, maxCaptionLength)
+		}
+		media = append(media, photo)
+	}
+
+	if _, err := h.bot.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+		log.Printf("Ошибка отправки альбома: %v", err)
+		return "Не удалось загрузить фото"
+	}
+	return ""
+}
+
+// отправляет достопримечательность точкой на карте, которую можно открыть
+// в приложении карт и построить маршрут. Возвращает текст для ответа на callback
+func (h *Handler) handleMapCallback(chatID int64, payload callbackPayload) string {
+	id, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+	if detail.Latitude == 0 && detail.Longitude == 0 {
+		return "У этой достопримечательности нет координат"
+	}
+
+	// Telegram не принимает точку без адреса
+	address := htmltext.Clean(detail.Address)
+	if address == "" {
+		address = htmltext.Clean(detail.City)
+	}
+	if address == "" {
+		address = htmltext.Clean(detail.Name)
+	}
+	venue := tgbotapi.NewVenue(chatID, htmltext.Clean(detail.Name), address, detail.Latitude, detail.Longitude)
+	if _, err := h.bot.Send(venue); err != nil {
+		log.Printf("Ошибка отправки точки на карте: %v", err)
+		return "Не удалось отправить точку на карте"
+	}
+	return ""
+}
+
+// fullDescription полное описание, а если его нет, то краткое
+func fullDescription(detail models.AttractionDetail) string {
+	if description := htmltext.Clean(detail.FullDescription); description != "" {
+		return description
+	}
+	return htmltext.Clean(detail.Description)
+}
+
+// отправляет полное описание, разбивая его на сообщения допустимой длины.
+// Возвращает текст для ответа на callback
+func (h *Handler) handleMoreCallback(chatID int64, payload callbackPayload) string {
+	id, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+	description := fullDescription(detail)
+	if description == "" {
+		return "У этой достопримечательности нет описания"
+	}
+
+	text := "📖 " + htmltext.Clean(detail.Name) + "\n\n" + description
+	for _, part := range htmltext.Split(text, maxMessageLength) {
+		if _, err := h.bot.Send(tgbotapi.NewMessage(chatID, part)); err != nil {
+			log.Printf("Ошибка отправки описания: %v", err)
+			return "Не удалось отправить описание"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"sort"
+	"tg-bot/geo"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// hasCoordinates сообщает, известны ли координаты достопримечательности.
+// API отдает нули, если координат нет
+func hasCoordinates(attr models.Attraction) bool {
+	return attr.Latitude != 0 || attr.Longitude != 0
+}
+
+// distanceTo расстояние от точки до достопримечательности в метрах
+func distanceTo(location *tgbotapi.Location, attr models.Attraction) float64 {
+	return geo.Distance(location.Latitude, location.Longitude, attr.Latitude, attr.Longitude)
+}
+
+// sortByDistance упорядочивает достопримечательности от ближней к дальней.
+// Достопримечательности без координат оказываются в конце
+func sortByDistance(attractions []models.Attraction, location *tgbotapi.Location) {
+	sort.SliceStable(attractions, func(i, j int) bool {
+		a, b := attractions[i], attractions[j]
+		if hasCoordinates(a) != hasCoordinates(b) {
+			return hasCoordinates(a)
+		}
+		if !hasCoordinates(a) {
+			return false
+		}
+		return distanceTo(location, a) < distanceTo(location, b)
+	})
+}
+
+// formatDistanceLine строка списка с расстоянием, направлением и временем пешком
+func formatDistanceLine(location *tgbotapi.Location, attr models.Attraction) string {
+	distance := distanceTo(location, attr)
+	point, arrow := geo.Compass(geo.Bearing(location.Latitude, location.Longitude, attr.Latitude, attr.Longitude))
+	return htmltext.Format("   🚶 %s %s %s · ~%s пешком\n",
+		geo.FormatDistance(distance), arrow, point, geo.FormatDuration(geo.WalkingMinutes(distance)))
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"tg-bot/models"
+	"tg-bot/session"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действие кнопки избранного, аргумент ID достопримечательности
+const actionFavorite = "fv"
+
+func (h *Handler) handleFavorites(msg *tgbotapi.Message, _ string) {
+	favorites := h.store.Favorites(userID(msg))
+	if len(favorites) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID,
+			"⭐ В избранном пока пусто. Откройте карточку достопримечательности и нажмите «⭐ В избранное»."))
+		return
+	}
+
+	h.startPagination(msg.Chat.ID, &session.PaginationState{
+		Type:        session.SearchTypeFavorites,
+		Attractions: favorites,
+	})
+}
+
+// кнопка добавления в избранное или удаления из него
+func favoriteButton(searchID string, attractionID int, favorite bool) tgbotapi.InlineKeyboardButton {
+	label := "⭐ В избранное"
+	if favorite {
+		label = "💔 Убрать из избранного"
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, callbackData(actionFavorite, searchID, attractionID))
+}
+
+// добавляет достопримечательность в избранное или убирает ее оттуда
+// и обновляет кнопку в карточке. Возвращает текст для ответа на callback
+func (h *Handler) handleFavoriteCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	id, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+	chatID := query.Message.Chat.ID
+	userID := query.From.ID
+
+	// Детали нужны для списка избранного и для новой клавиатуры карточки,
+	// обычно они уже лежат в кэше после показа карточки
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+
+	var err error
+	if session.IsFavorite(h.store.Favorites(userID), id) {
+		err = h.store.RemoveFavorite(userID, id)
+	} else {
+		err = h.store.AddFavorite(userID, attractionSummary(detail))
+	}
+	switch {
+	case errors.Is(err, session.ErrFavoritesFull):
+		return fmt.Sprintf("⭐ В избранном уже %d мест. Уберите что-нибудь, чтобы добавить новое.", session.MaxFavorites)
+	case err != nil:
+		log.Printf("Ошибка сохранения избранного пользователя %d: %v", userID, err)
+		return "❌ Не удалось сохранить избранное. Попробуйте позже."
+	}
+
+	h.editMessage(tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID,
+		h.detailKeyboard(chatID, userID, payload.SearchID, detail)))
+	return ""
+}
+
+// attractionSummary краткие данные достопримечательности для списка
+func attractionSummary(detail models.AttractionDetail) models.Attraction {
+	return models.Attraction{
+		ID:           detail.ID,
+		Name:         detail.Name,
+		City:         detail.City,
+		Address:      detail.Address,
+		Description:  detail.Description,
+		Rating:       detail.Rating,
+		MainPhotoURL: detail.MainPhotoURL,
+		Latitude:     detail.Latitude,
+		Longitude:    detail.Longitude,
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+	"tg-bot/session"
+	"tg-bot/textsearch"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок сортировки и фильтров
+const (
+	actionSort   = "s" // сортировка, аргумент session.SortMode
+	actionFilter = "f" // переключение фильтра, аргумент filterRating или filterPhoto
+)
+
+// Фильтры списка
+const (
+	filterRating = "r"  // рейтинг не ниже minRatingFilter
+	filterPhoto  = "ph" // только с фото
+)
+
+// Порог фильтра по рейтингу
+const minRatingFilter = 4.0
+
+// pageCount число страниц для n достопримечательностей. Пустой список
+// занимает одну страницу, чтобы на ней остались кнопки фильтров
+func pageCount(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return (n + pageSize - 1) / pageSize
+}
+
+// visibleAttractions применяет к результатам поиска фильтры и сортировку.
+// state.Attractions не изменяется
+func visibleAttractions(state *session.PaginationState) []models.Attraction {
+	query := textsearch.NewQuery(state.Query)
+	scores := make(map[int]float64)
+
+	visible := make([]models.Attraction, 0, len(state.Attractions))
+	for _, attr := range state.Attractions {
+		if state.MinRating > 0 && attr.Rating < state.MinRating {
+			continue
+		}
+		if state.WithPhoto && attr.MainPhotoURL == "" {
+			continue
+		}
+		if !query.Empty() {
+			score := attractionScore(query, attr)
+			if score == 0 {
+				continue
+			}
+			scores[attr.ID] = score
+		}
+		visible = append(visible, attr)
+	}
+
+	switch state.Sort {
+	case session.SortDefault:
+		// С запросом сначала идут самые подходящие
+		if !query.Empty() {
+			sort.SliceStable(visible, func(i, j int) bool {
+				return scores[visible[i].ID] > scores[visible[j].ID]
+			})
+		}
+	case session.SortRating:
+		sort.SliceStable(visible, func(i, j int) bool {
+			return visible[i].Rating > visible[j].Rating
+		})
+	case session.SortName:
+		sort.SliceStable(visible, func(i, j int) bool {
+			return nameKey(visible[i].Name) < nameKey(visible[j].Name)
+		})
+	case session.SortDistance:
+		if state.Location != nil {
+			sortByDistance(visible, state.Location)
+		}
+	}
+	return visible
+}
+
+// nameKey ключ сортировки по названию: без регистра, кавычек и с ё как е
+func nameKey(name string) string {
+	name = strings.ToLower(strings.TrimLeft(name, "\"«„ "))
+	return strings.ReplaceAll(name, "ё", "е")
+}
+
+// describeView строка заголовка с примененными фильтрами
+func describeView(state *session.PaginationState, visible int) string {
+	var filters []string
+	if state.MinRating > 0 {
+		filters = append(filters, fmt.Sprintf("⭐ от %.0f", state.MinRating))
+	}
+	if state.WithPhoto {
+		filters = append(filters, "📷 с фото")
+	}
+	if state.Query != "" {
+		filters = append(filters, "🔍 «"+htmltext.Truncate(state.Query, 50)+"»")
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return htmltext.Format("Фильтры: %s — %d из %d\n", strings.Join(filters, ", "), visible, len(state.Attractions))
+}
+
+// кнопки сортировки, выбранная отмечена галочкой
+func sortButtons(state *session.PaginationState) []tgbotapi.InlineKeyboardButton {
+	type option struct {
+		mode  session.SortMode
+		label string
+	}
+	options := []option{
+		{session.SortRating, "⭐ Рейтинг"},
+		{session.SortName, "🔤 Название"},
+	}
+	if state.Type == session.SearchTypeLocation {
+		options = append(options, option{session.SortDistance, "📏 Расстояние"})
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, o := range options {
+		label := o.label
+		if state.Sort == o.mode {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label,
+			callbackData(actionSort, state.SearchID, string(o.mode))))
+	}
+	return row
+}
+
+// кнопки фильтров, включенные отмечены галочкой
+func filterButtons(state *session.PaginationState) []tgbotapi.InlineKeyboardButton {
+	ratingLabel := fmt.Sprintf("⭐ %.0f+", minRatingFilter)
+	if state.MinRating > 0 {
+		ratingLabel = "✅ " + ratingLabel
+	}
+	photoLabel := "📷 С фото"
+	if state.WithPhoto {
+		photoLabel = "✅ " + photoLabel
+	}
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(ratingLabel, callbackData(actionFilter, state.SearchID, filterRating)),
+		tgbotapi.NewInlineKeyboardButtonData(photoLabel, callbackData(actionFilter, state.SearchID, filterPhoto)),
+	)
+}
+
+// меняет сортировку или фильтры поиска и перерисовывает список с первой страницы.
+// Возвращает текст для ответа на callback
+func (h *Handler) handleViewCallback(message *tgbotapi.Message, payload callbackPayload) string {
+	if len(payload.Args) != 1 {
+		return expiredText
+	}
+	chatID := message.Chat.ID
+
+	unlock := h.store.Lock(chatID)
+	defer unlock()
+
+	state, exists := h.store.Search(chatID, payload.SearchID)
+	if !exists {
+		return expiredText
+	}
+
+	switch payload.Action {
+	case actionSort:
+		mode := session.SortMode(payload.Args[0])
+		switch mode {
+		case session.SortRating, session.SortName, session.SortDistance:
+		default:
+			return expiredText
+		}
+		// Повторное нажатие возвращает исходный порядок
+		if state.Sort == mode {
+			mode = session.SortDefault
+		}
+		state.Sort = mode
+	case actionFilter:
+		switch payload.Args[0] {
+		case filterRating:
+			if state.MinRating > 0 {
+				state.MinRating = 0
+			} else {
+				state.MinRating = minRatingFilter
+			}
+		case filterPhoto:
+			state.WithPhoto = !state.WithPhoto
+		default:
+			return expiredText
+		}
+	}
+
+	h.sendAttractionsPage(chatID, message.MessageID, state, 0)
+	return ""
+}
@@ -2,273 +2,347 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"tg-bot/api"
+	"tg-bot/cities"
+	"tg-bot/htmltext"
 	"tg-bot/models"
-	"unicode/utf8"
+	"tg-bot/session"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// Добавим тип для определения типа поиска
-type SearchType int
+// Сколько обработчик ждет ответа API с учетом повторов
+const requestTimeout = 30 * time.Second
 
-const (
-	SearchTypeCity SearchType = iota
-	SearchTypeLocation
-)
+// Сколько достопримечательностей показывается на одной странице
+const pageSize = 5
 
-// Обновим структуру для хранения состояния пагинации
-type PaginationState struct {
-	Type        SearchType
-	City        string
-	Location    *tgbotapi.Location
-	Attractions []models.Attraction
-	Page        int
-	TotalPages  int
+// Handler обрабатывает обновления бота, используя переданный клиент API
+// и хранилище состояний пагинации
+type Handler struct {
+	bot   *tgbotapi.BotAPI
+	api   api.Service
+	store session.Store
+	live  *liveTracker
 }
 
-// Глобальная map для хранения состояний пагинации по chatID
-var paginationStates = make(map[int64]*PaginationState)
+// New создает обработчик обновлений
+func New(bot *tgbotapi.BotAPI, service api.Service, store session.Store) *Handler {
+	return &Handler{
+		bot:   bot,
+		api:   service,
+		store: store,
+		live:  newLiveTracker(),
+	}
+}
 
-func HandleMessage(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+// обрабатывает команду /start
+func (h *Handler) HandleMessage(update tgbotapi.Update) {
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
 
-	if update.Message.Text == "/start" {
-		msg.Text = "Привет! Я помогу найти интересные достопримечательности.\n\n Отправь мне название города (например: \"Москва\", \"Санкт-Петербург\")\n🗺️ Или отправь свою геолокацию для поиска рядом с тобой"
-		msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
-			tgbotapi.NewKeyboardButtonRow(
-				tgbotapi.NewKeyboardButtonLocation(" Отправить геолокацию"),
-			),
-		)
-	} else {
-		// Обрабатываем как название города
-		go HandleCity(bot, update)
-	}
+	msg.Text = "Привет! Я помогу найти интересные достопримечательности.\n\n Отправь мне название города (например: \"Москва\", \"Санкт-Петербург\")\n🗺️ Или отправь свою геолокацию для поиска рядом с тобой\n\nВсе команды: /help"
+	msg.ReplyMarkup = locationKeyboard()
 
-	bot.Send(msg)
+	h.bot.Send(msg)
 }
 
-// обрабатывает поиск достопримечательностей по городу
-func HandleCity(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
+// клавиатура с кнопкой отправки геолокации
+func locationKeyboard() tgbotapi.ReplyKeyboardMarkup {
+	return tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButtonLocation(" Отправить геолокацию"),
+		),
+	)
+}
 
-	// Очищаем название города
-	cityName := cleanUTF8(update.Message.Text)
+// обрабатывает сообщение с названием города
+func (h *Handler) HandleCity(update tgbotapi.Update) {
+	// После кнопки «Поиск в результатах» сообщение — это запрос, а не город
+	if h.searchInResults(update.Message) {
+		return
+	}
+	h.searchCity(update.Message.Chat.ID, update.Message.Text)
+}
+
+// ищет достопримечательности по городу
+func (h *Handler) searchCity(chatID int64, city string) {
+	msg := tgbotapi.NewMessage(chatID, "")
+
+	// Запрос вида «Ярославль: музей» ищет среди достопримечательностей города
+	city, query := splitCityQuery(htmltext.Clean(city))
+
+	// Очищаем название города и узнаем его, если оно написано
+	// латиницей, с «г.» или разговорным словом
+	cityName, _ := cities.Normalize(strings.TrimSpace(city))
 
 	// Получаем достопримечательности по городу через API
-	attractions, err := api.GetAttractionsByCity(cityName)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	attractions, err := h.api.AttractionsByCity(ctx, cityName)
+	if errors.Is(err, api.ErrNotFound) {
+		// API отвечает 404 на неизвестный город
+		attractions, err = nil, nil
+	}
 	if err != nil {
 		log.Printf("Ошибка при запросе к API: %v", err)
-		msg.Text = "❌ Ошибка при поиске достопримечательностей. Попробуйте позже."
-		bot.Send(msg)
+		msg.Text = apiErrorText(err, "❌ Ошибка при поиске достопримечательностей. Попробуйте позже.")
+		h.bot.Send(msg)
 		return
 	}
 
 	// Очищаем полученные данные
 	for i := range attractions {
-		attractions[i].Name = cleanUTF8(attractions[i].Name)
-		attractions[i].Address = cleanUTF8(attractions[i].Address)
-		attractions[i].Description = cleanUTF8(attractions[i].Description)
-		attractions[i].City = cleanUTF8(attractions[i].City)
+		attractions[i].Name = htmltext.Clean(attractions[i].Name)
+		attractions[i].Address = htmltext.Clean(attractions[i].Address)
+		attractions[i].Description = htmltext.Clean(attractions[i].Description)
+		attractions[i].City = htmltext.Clean(attractions[i].City)
 	}
 
 	if len(attractions) == 0 {
-		msg.Text = safeFormat("🏙️ В городе \"%s\" не найдено достопримечательностей 😢\nПопробуйте другой город или проверьте написание.", cityName)
-		bot.Send(msg)
+		msg.Text = fmt.Sprintf("🏙️ В городе \"%s\" не найдено достопримечательностей 😢\nПопробуйте другой город или проверьте написание.", cityName)
+		if keyboard, ok := citySuggestions(cityName); ok {
+			msg.Text += "\n\nВозможно, вы имели в виду:"
+			msg.ReplyMarkup = keyboard
+		}
+		h.bot.Send(msg)
 		return
 	}
 
 	// Сохраняем состояние пагинации
-	pageSize := 5
-	totalPages := (len(attractions) + pageSize - 1) / pageSize
+	totalPages := pageCount(len(attractions))
 
-	paginationStates[update.Message.Chat.ID] = &PaginationState{
-		Type:        SearchTypeCity,
+	h.startPagination(chatID, &session.PaginationState{
+		Type:        session.SearchTypeCity,
 		City:        cityName,
 		Location:    nil,
 		Attractions: attractions,
 		Page:        0,
 		TotalPages:  totalPages,
-	}
-
-	// Отправляем первую страницу
-	sendAttractionsPage(bot, update.Message.Chat.ID, 0)
+		Query:       query,
+	})
 }
 
 // обрабатывает сообщения с геолокацией
-func HandleLocation(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
+func (h *Handler) HandleLocation(update tgbotapi.Update) {
+	from := userID(update.Message)
+	// Последняя геолокация становится началом пешеходного маршрута
+	h.saveRouteStart(from, update.Message.Location)
+
+	prefs := h.store.Preferences(from)
+	h.searchNearby(update.Message.Chat.ID, update.Message.Location, prefs.Radius)
+
+	if update.Message.Location.LivePeriod > 0 {
+		h.startLive(update.Message)
+	}
+}
+
+// ищет достопримечательности в радиусе radius метров от location
+func (h *Handler) searchNearby(chatID int64, location *tgbotapi.Location, radius int) {
+	msg := tgbotapi.NewMessage(chatID, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
 
 	// Получаем достопримечательности вокруг локации
-	attractions, err := api.GetAttractionsByLocation(
-		update.Message.Location.Latitude,
-		update.Message.Location.Longitude,
-		0.01,
+	attractions, err := h.api.AttractionsByLocation(
+		ctx,
+		location.Latitude,
+		location.Longitude,
+		api.RadiusFromMeters(radius),
 	)
 	for i := range attractions {
-		attractions[i].Name = cleanUTF8(attractions[i].Name)
-		attractions[i].Address = cleanUTF8(attractions[i].Address)
-		attractions[i].Description = cleanUTF8(attractions[i].Description)
-		attractions[i].City = cleanUTF8(attractions[i].City)
+		attractions[i].Name = htmltext.Clean(attractions[i].Name)
+		attractions[i].Address = htmltext.Clean(attractions[i].Address)
+		attractions[i].Description = htmltext.Clean(attractions[i].Description)
+		attractions[i].City = htmltext.Clean(attractions[i].City)
 	}
 
 	if err != nil {
 		log.Printf("Ошибка при запросе геолокации: %v", err)
-		msg.Text = " Ошибка при поиске достопримечательностей по геолокации."
-		bot.Send(msg)
+		msg.Text = apiErrorText(err, " Ошибка при поиске достопримечательностей по геолокации.")
+		h.bot.Send(msg)
 		return
 	}
 
 	if len(attractions) == 0 {
-		msg.Text = " Рядом с вами не найдено достопримечательностей \nПопробуйте увеличить радиус поиска или отправьте название города."
-		bot.Send(msg)
+		msg.Text = fmt.Sprintf(" В радиусе %s от вас не найдено достопримечательностей \nПопробуйте увеличить радиус поиска или отправьте название города.", formatRadius(radius))
+		if keyboard, ok := widerSearchKeyboard(location, radius); ok {
+			msg.ReplyMarkup = keyboard
+		}
+		h.bot.Send(msg)
 		return
 	}
 
 	// Сохраняем состояние пагинации
-	pageSize := 5
-	totalPages := (len(attractions) + pageSize - 1) / pageSize
+	totalPages := pageCount(len(attractions))
 
 	// Сохраняем копию локации
 	locationCopy := &tgbotapi.Location{
-		Latitude:  update.Message.Location.Latitude,
-		Longitude: update.Message.Location.Longitude,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
 	}
 
-	paginationStates[update.Message.Chat.ID] = &PaginationState{
-		Type:        SearchTypeLocation,
+	// Результаты хранятся в порядке API, а ближние сначала показывает
+	// сортировка по расстоянию, которую можно выключить
+	h.startPagination(chatID, &session.PaginationState{
+		Type:        session.SearchTypeLocation,
 		City:        "",
 		Location:    locationCopy,
 		Attractions: attractions,
 		Page:        0,
 		TotalPages:  totalPages,
-	}
-
-	// Отправляем первую страницу
-	sendAttractionsPage(bot, update.Message.Chat.ID, 0)
-}
-func cleanUTF8(s string) string {
-	if utf8.ValidString(s) {
-		return s
-	}
-
-	// Если строка содержит невалидные UTF8 символы, очищаем их
-	v := make([]rune, 0, len(s))
-	for i, r := range s {
-		if r == utf8.RuneError {
-			_, size := utf8.DecodeRuneInString(s[i:])
-			if size == 1 {
-				continue // Пропускаем невалидный символ
-			}
-		}
-		v = append(v, r)
-	}
-	return string(v)
+		Sort:        session.SortDistance,
+	})
 }
 
-// Функция для безопасного форматирования строки
-func safeFormat(format string, args ...interface{}) string {
-	// Очищаем все аргументы
-	cleanArgs := make([]interface{}, len(args))
-	for i, arg := range args {
-		if s, ok := arg.(string); ok {
-			cleanArgs[i] = cleanUTF8(s)
-		} else {
-			cleanArgs[i] = arg
-		}
-	}
-	return fmt.Sprintf(format, cleanArgs...)
+// сохраняет состояние нового поиска и отправляет первую страницу
+func (h *Handler) startPagination(chatID int64, state *session.PaginationState) {
+	unlock := h.store.Lock(chatID)
+	defer unlock()
+
+	state.SearchID = session.NewSearchID()
+
+	// Отправляем первую страницу, она же сохраняет состояние
+	h.sendAttractionsPage(chatID, 0, state, 0)
 }
 
-// отправляет страницу с достопримечательностями
-func sendAttractionsPage(bot *tgbotapi.BotAPI, chatID int64, page int) {
-	state, exists := paginationStates[chatID]
-	if !exists || len(state.Attractions) == 0 {
+// отправляет страницу с достопримечательностями поиска state.
+// Если messageID не 0, страница заменяет текст этого сообщения.
+// Вызывается под блокировкой чата
+func (h *Handler) sendAttractionsPage(chatID int64, messageID int, state *session.PaginationState, page int) {
+	if len(state.Attractions) == 0 {
 		return
 	}
 
+	// Показываем список с учетом сортировки и фильтров
+	visible := visibleAttractions(state)
+	state.TotalPages = pageCount(len(visible))
+
 	// Проверяем границы страницы
-	if page < 0 {
-		page = 0
-	}
 	if page >= state.TotalPages {
 		page = state.TotalPages - 1
 	}
+	if page < 0 {
+		page = 0
+	}
 
 	state.Page = page
-	pageSize := 5
+	if err := h.store.Put(chatID, state); err != nil {
+		log.Printf("Ошибка сохранения состояния чата %d: %v", chatID, err)
+	}
+
 	start := page * pageSize
 	end := start + pageSize
-	if end > len(state.Attractions) {
-		end = len(state.Attractions)
+	if end > len(visible) {
+		end = len(visible)
 	}
 
 	// Формируем заголовок сообщения в зависимости от типа поиска
 	var header string
-	if state.Type == SearchTypeCity {
-		header = safeFormat("🏙️ Достопримечательности в %s (стр. %d/%d):\n\n",
+	switch state.Type {
+	case session.SearchTypeCity:
+		header = htmltext.Format("🏙️ Достопримечательности в %s (стр. %d/%d):\n",
 			state.City, page+1, state.TotalPages)
-	} else {
-		header = safeFormat("📍 Достопримечательности рядом с вами (стр. %d/%d):\n\n",
+	case session.SearchTypeFavorites:
+		header = htmltext.Format("⭐ Избранное (стр. %d/%d):\n",
+			page+1, state.TotalPages)
+	default:
+		header = htmltext.Format("📍 Достопримечательности рядом с вами (стр. %d/%d):\n",
 			page+1, state.TotalPages)
 	}
 
 	// Формируем сообщение
 	var builder strings.Builder
 	builder.WriteString(header)
+	builder.WriteString(describeView(state, len(visible)))
+	builder.WriteString("\n")
 
-	for i := start; i < end; i++ {
-		attr := state.Attractions[i]
+	if len(visible) == 0 && state.Query != "" {
+		builder.WriteString("🤷 По запросу ничего не нашлось. Сбросьте поиск или фильтры кнопками ниже.\n")
+	} else if len(visible) == 0 {
+		builder.WriteString("🤷 Под выбранные фильтры ничего не подходит. Отключите их кнопками ниже.\n")
+	}
 
-		// Очищаем все текстовые поля
-		cleanName := cleanUTF8(attr.Name)
-		cleanAddress := cleanUTF8(attr.Address)
-		cleanDescription := cleanUTF8(attr.Description)
+	for i := start; i < end; i++ {
+		attr := visible[i]
 
 		ratingText := ""
 		if attr.Rating > 0 {
-			ratingText = safeFormat(" (⭐ %.1f)", attr.Rating)
+			ratingText = fmt.Sprintf(" (⭐ %.1f)", attr.Rating)
 		}
 
-		builder.WriteString(safeFormat("%d. %s%s\n", i+1, cleanName, ratingText))
+		// Format экранирует данные API, чтобы они не ломали HTML-разметку
+		builder.WriteString(htmltext.Format("%d. %s%s\n", i+1, htmltext.Truncate(attr.Name, 100), ratingText))
+
+		if attr.Address != "" {
+			builder.WriteString(htmltext.Format("   📍 %s\n", htmltext.Truncate(attr.Address, 50)))
+		}
 
-		if cleanAddress != "" {
-			builder.WriteString(safeFormat("   📍 %s\n", truncateString(cleanAddress, 50)))
+		if attr.Description != "" {
+			builder.WriteString(htmltext.Format("   📝 %s\n", htmltext.Truncate(attr.Description, 50)))
 		}
 
-		if cleanDescription != "" {
-			builder.WriteString(safeFormat("   📝 %s\n", truncateString(cleanDescription, 50)))
+		if state.Type == session.SearchTypeLocation && state.Location != nil && hasCoordinates(attr) {
+			builder.WriteString(formatDistanceLine(state.Location, attr))
 		}
 
 		builder.WriteString("\n")
 	}
 
 	// Создаем клавиатуру с пагинацией
-	keyboard := createPaginationKeyboard(page, state.TotalPages, start, end)
+	keyboard := createPaginationKeyboard(state, visible, start, end)
+
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, builder.String(), keyboard)
+		edit.ParseMode = "HTML"
+		if h.editMessage(edit) {
+			return
+		}
+	}
 
 	msg := tgbotapi.NewMessage(chatID, builder.String())
 	msg.ReplyMarkup = keyboard
 	msg.ParseMode = "HTML" // Используем HTML parse mode для лучшей совместимости
-	bot.Send(msg)
+	h.bot.Send(msg)
+}
+
+// редактирует сообщение. Возвращает false, если отредактировать не удалось
+// (сообщение слишком старое, удалено или это фото) и нужно отправить новое
+func (h *Handler) editMessage(edit tgbotapi.Chattable) bool {
+	_, err := h.bot.Request(edit)
+	if err == nil {
+		return true
+	}
+	// Повторное нажатие на ту же кнопку: сообщение уже в нужном виде
+	if strings.Contains(err.Error(), "message is not modified") {
+		return true
+	}
+	log.Printf("Не удалось отредактировать сообщение: %v", err)
+	return false
 }
 
-// создает клавиатуру для пагинации
-func createPaginationKeyboard(currentPage, totalPages, start, end int) tgbotapi.InlineKeyboardMarkup {
+// создает клавиатуру для пагинации по списку visible
+func createPaginationKeyboard(state *session.PaginationState, visible []models.Attraction, start, end int) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
 
 	// Кнопки навигации
 	var navButtons []tgbotapi.InlineKeyboardButton
 
-	if currentPage > 0 {
-		navButtons = append(navButtons, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", fmt.Sprintf("page_%d", currentPage-1)))
+	if state.Page > 0 {
+		navButtons = append(navButtons, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад",
+			callbackData(actionPage, state.SearchID, state.Page-1)))
 	}
 
-	if currentPage < totalPages-1 {
-		navButtons = append(navButtons, tgbotapi.NewInlineKeyboardButtonData("Вперед ➡️", fmt.Sprintf("page_%d", currentPage+1)))
+	if state.Page < state.TotalPages-1 {
+		navButtons = append(navButtons, tgbotapi.NewInlineKeyboardButtonData("Вперед ➡️",
+			callbackData(actionPage, state.SearchID, state.Page+1)))
 	}
 
 	if len(navButtons) > 0 {
@@ -279,69 +353,30 @@ func createPaginationKeyboard(currentPage, totalPages, start, end int) tgbotapi.
 	for i := start; i < end; i++ {
 		btn := tgbotapi.NewInlineKeyboardButtonData(
 			fmt.Sprintf("🏛️ %d", i+1),
-			fmt.Sprintf("attraction_%d", i),
+			callbackData(actionAttraction, state.SearchID, visible[i].ID),
 		)
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
 	}
 
-	return tgbotapi.NewInlineKeyboardMarkup(rows...)
-}
-
-// обрабатывает callback-и от inline кнопок
-func HandleCallback(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
-	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
-	bot.Send(callback)
-
-	msg := tgbotapi.NewMessage(update.CallbackQuery.Message.Chat.ID, "")
-
-	data := update.CallbackQuery.Data
-
-	if strings.HasPrefix(data, "page_") {
-		// Обработка пагинации
-		pageStr := strings.TrimPrefix(data, "page_")
-		page, err := strconv.Atoi(pageStr)
-		if err == nil {
-			sendAttractionsPage(bot, update.CallbackQuery.Message.Chat.ID, page)
-		}
-		return
-	}
-
-	if strings.HasPrefix(data, "attraction_") {
-		// Обработка выбора достопримечательности
-		indexStr := strings.TrimPrefix(data, "attraction_")
-		index, err := strconv.Atoi(indexStr)
-		if err != nil {
-			msg.Text = "Ошибка выбора"
-		} else {
-			state, exists := paginationStates[update.CallbackQuery.Message.Chat.ID]
-			if exists && index >= 0 && index < len(state.Attractions) {
-				detail, err := api.GetAttractionDetail(state.Attractions[index].ID)
-				if err != nil {
-					msg.Text = " Ошибка при загрузке деталей"
-				} else {
-					msg.Text = formatAttractionDetail(detail)
-					// Добавляем кнопку назад к списку
-					msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
-						tgbotapi.NewInlineKeyboardRow(
-							tgbotapi.NewInlineKeyboardButtonData("↩️ Назад к списку",
-								fmt.Sprintf("page_%d", state.Page)),
-						),
-					)
-				}
-			} else {
-				msg.Text = "Достопримечательность не найдена"
-			}
-		}
-	}
+	// Кнопки сортировки, фильтров и поиска
+	rows = append(rows, sortButtons(state), filterButtons(state),
+		tgbotapi.NewInlineKeyboardRow(searchButton(state)))
 
-	bot.Send(msg)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func truncateString(s string, maxLength int) string {
-	if len(s) <= maxLength {
-		return s
+// подбирает текст ошибки API для пользователя
+func apiErrorText(err error, fallback string) string {
+	switch {
+	case errors.Is(err, api.ErrCircuitOpen):
+		return "🔧 Сервис достопримечательностей временно недоступен. Попробуйте через несколько минут."
+	case errors.Is(err, api.ErrRateLimited):
+		return "⏳ Слишком много запросов. Попробуйте через минуту."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "⌛ Сервис достопримечательностей не ответил вовремя. Попробуйте позже."
+	default:
+		return fallback
 	}
-	return s[:maxLength-3] + "..."
 }
 
 // формирует детальное описание достопримечательности
@@ -349,56 +384,51 @@ func formatAttractionDetail(detail models.AttractionDetail) string {
 	var builder strings.Builder
 
 	// Очищаем все текстовые поля
-	cleanName := cleanUTF8(detail.Name)
-	cleanAddress := cleanUTF8(detail.Address)
-	cleanCity := cleanUTF8(detail.City)
-	cleanFullDescription := cleanUTF8(detail.FullDescription)
-	cleanDescription := cleanUTF8(detail.Description)
-	cleanWorkingHours := cleanUTF8(detail.WorkingHours)
-	cleanPhone := cleanUTF8(detail.Phone)
-	cleanWebsite := cleanUTF8(detail.Website)
-	cleanCost := cleanUTF8(detail.Cost)
-
-	builder.WriteString(safeFormat("<b>🏛️ %s</b>\n\n", cleanName))
+	cleanName := htmltext.Clean(detail.Name)
+	cleanAddress := htmltext.Clean(detail.Address)
+	cleanCity := htmltext.Clean(detail.City)
+	cleanFullDescription := htmltext.Clean(detail.FullDescription)
+	cleanDescription := htmltext.Clean(detail.Description)
+	cleanWorkingHours := htmltext.Clean(detail.WorkingHours)
+	cleanPhone := htmltext.Clean(detail.Phone)
+	cleanWebsite := htmltext.Clean(detail.Website)
+	cleanCost := htmltext.Clean(detail.Cost)
+
+	builder.WriteString(htmltext.Format("<b>🏛️ %s</b>\n\n", cleanName))
 
 	if cleanAddress != "" {
-		builder.WriteString(safeFormat("📍 <b>Адрес:</b> %s\n", cleanAddress))
+		builder.WriteString(htmltext.Format("📍 <b>Адрес:</b> %s\n", cleanAddress))
 	}
 
 	if cleanCity != "" {
-		builder.WriteString(safeFormat("🏙️ <b>Город:</b> %s\n", cleanCity))
+		builder.WriteString(htmltext.Format("🏙️ <b>Город:</b> %s\n", cleanCity))
 	}
 
+	// Полный текст доступен по кнопке «Читать дальше»
 	if cleanFullDescription != "" {
-		builder.WriteString(safeFormat("\n📖 <b>Описание:</b> %s\n", truncateString(cleanFullDescription, 200)))
+		builder.WriteString(htmltext.Format("\n📖 <b>Описание:</b> %s\n", htmltext.Truncate(cleanFullDescription, descriptionPreviewLength)))
 	} else if cleanDescription != "" {
-		builder.WriteString(safeFormat("\n📖 <b>Описание:</b> %s\n", truncateString(cleanDescription, 200)))
+		builder.WriteString(htmltext.Format("\n📖 <b>Описание:</b> %s\n", htmltext.Truncate(cleanDescription, descriptionPreviewLength)))
 	}
 
 	if cleanWorkingHours != "" {
-		builder.WriteString(safeFormat("🕒 <b>Часы работы:</b> %s\n", cleanWorkingHours))
+		builder.WriteString(htmltext.Format("🕒 <b>Часы работы:</b> %s\n", cleanWorkingHours))
 	}
 
 	if cleanPhone != "" {
-		builder.WriteString(safeFormat("📞 <b>Телефон:</b> %s\n", cleanPhone))
+		builder.WriteString(htmltext.Format("📞 <b>Телефон:</b> %s\n", cleanPhone))
 	}
 
 	if cleanWebsite != "" {
-		builder.WriteString(safeFormat("🌐 <b>Сайт:</b> %s\n", cleanWebsite))
+		builder.WriteString(htmltext.Format("🌐 <b>Сайт:</b> %s\n", cleanWebsite))
 	}
 
 	if cleanCost != "" {
-		builder.WriteString(safeFormat("💵 <b>Стоимость:</b> %s\n", cleanCost))
+		builder.WriteString(htmltext.Format("💵 <b>Стоимость:</b> %s\n", cleanCost))
 	}
 
 	if detail.Rating > 0 {
-		builder.WriteString(safeFormat("\n⭐ <b>Рейтинг:</b> %.1f/5\n", detail.Rating))
-	}
-
-	// Добавляем фото, если есть
-	if detail.MainPhotoURL != "" {
-		cleanPhotoURL := cleanUTF8(detail.MainPhotoURL)
-		builder.WriteString(safeFormat("\n📸 <a href=\"%s\">Фото</a>", cleanPhotoURL))
+		builder.WriteString(htmltext.Format("\n⭐ <b>Рейтинг:</b> %.1f/5\n", detail.Rating))
 	}
 
 	return builder.String()
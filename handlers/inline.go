@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"tg-bot/api"
+	"tg-bot/cities"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько результатов отдается за один запрос, Telegram принимает до 50
+const inlinePageSize = 20
+
+// Сколько секунд Telegram может отдавать ответ на тот же запрос из своего кэша
+const (
+	inlineCacheTime      = 300
+	inlineErrorCacheTime = 10
+)
+
+// Название города в запросе может состоять из нескольких слов
+const maxCityWords = 3
+
+// Префикс параметра /start, открывающего карточку: /start a123
+const startAttractionPrefix = "a"
+
+// отвечает на inline-запрос вида «@bot Ярославль собор»: сначала город,
+// затем необязательная часть названия достопримечательности
+func (h *Handler) HandleInlineQuery(update tgbotapi.Update) {
+	query := update.InlineQuery
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       []interface{}{},
+		CacheTime:     inlineCacheTime,
+	}
+
+	text := strings.TrimSpace(htmltext.Clean(query.Query))
+	if text == "" {
+		answer.SwitchPMText = "🔍 Напишите город, например: Ярославль собор"
+		answer.SwitchPMParameter = "inline"
+		h.answerInline(answer)
+		return
+	}
+
+	attractions, err := h.inlineSearch(text)
+	if err != nil {
+		log.Printf("Ошибка inline-поиска %q: %v", text, err)
+		answer.CacheTime = inlineErrorCacheTime
+		h.answerInline(answer)
+		return
+	}
+
+	// Смещение — индекс первого результата следующей порции
+	offset, _ := strconv.Atoi(query.Offset)
+	if offset < 0 || offset > len(attractions) {
+		offset = len(attractions)
+	}
+	end := offset + inlinePageSize
+	if end > len(attractions) {
+		end = len(attractions)
+	}
+	if end < len(attractions) {
+		answer.NextOffset = strconv.Itoa(end)
+	}
+
+	for _, attr := range attractions[offset:end] {
+		answer.Results = append(answer.Results, h.inlineResult(attr))
+	}
+	if len(attractions) == 0 {
+		answer.SwitchPMText = "🤷 Ничего не нашлось, поискать в боте"
+		answer.SwitchPMParameter = "inline"
+	}
+	h.answerInline(answer)
+}
+
+func (h *Handler) answerInline(answer tgbotapi.InlineConfig) {
+	if _, err := h.bot.Request(answer); err != nil {
+		log.Printf("Ошибка ответа на inline-запрос: %v", err)
+	}
+}
+
+// inlineSearch ищет город по первым словам запроса, а остальные слова
+// использует как фильтр по названию. Ответы API кэшируются, поэтому
+// перебор вариантов города обходится недорого
+func (h *Handler) inlineSearch(text string) ([]models.Attraction, error) {
+	words := strings.Fields(text)
+
+	var lastErr error
+	for n := 1; n <= len(words) && n <= maxCityWords; n++ {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		city, _ := cities.Normalize(strings.Join(words[:n], " "))
+		attractions, err := h.api.AttractionsByCity(ctx, city)
+		cancel()
+		if errors.Is(err, api.ErrNotFound) || (err == nil && len(attractions) == 0) {
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return filterByName(attractions, words[n:]), nil
+	}
+	return nil, lastErr
+}
+
+// filterByName оставляет достопримечательности, в названии которых
+// есть все слова words
+func filterByName(attractions []models.Attraction, words []string) []models.Attraction {
+	if len(words) == 0 {
+		return attractions
+	}
+	var matched []models.Attraction
+	for _, attr := range attractions {
+		name := nameKey(attr.Name)
+		all := true
+		for _, word := range words {
+			if !strings.Contains(name, nameKey(word)) {
+				all = false
+				break
+			}
+		}
+		if all {
+			matched = append(matched, attr)
+		}
+	}
+	return matched
+}
+
+// inlineResult статья с кратким описанием достопримечательности
+// и ссылкой на карточку в боте
+func (h *Handler) inlineResult(attr models.Attraction) tgbotapi.InlineQueryResultArticle {
+	name := htmltext.Clean(attr.Name)
+	address := htmltext.Clean(attr.Address)
+
+	var builder strings.Builder
+	builder.WriteString(htmltext.Format("<b>🏛️ %s</b>\n", name))
+	if address != "" {
+		builder.WriteString(htmltext.Format("📍 %s\n", address))
+	}
+	if attr.Rating > 0 {
+		builder.WriteString(fmt.Sprintf("⭐ %.1f/5\n", attr.Rating))
+	}
+	if description := htmltext.Clean(attr.Description); description != "" {
+		builder.WriteString(htmltext.Format("\n%s\n", htmltext.Truncate(description, descriptionPreviewLength)))
+	}
+
+	result := tgbotapi.NewInlineQueryResultArticleHTML(strconv.Itoa(attr.ID), htmltext.Truncate(name, 100), builder.String())
+	result.ThumbURL = attr.MainPhotoURL
+
+	var details []string
+	if attr.Rating > 0 {
+		details = append(details, fmt.Sprintf("⭐ %.1f", attr.Rating))
+	}
+	if address != "" {
+		details = append(details, address)
+	}
+	result.Description = htmltext.Truncate(strings.Join(details, " · "), 100)
+
+	link := fmt.Sprintf("https://t.me/%s?start=%s%d", h.bot.Self.UserName, startAttractionPrefix, attr.ID)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonURL("🤖 Подробнее в боте", link),
+	))
+	result.ReplyMarkup = &keyboard
+	return result
+}
+
+// показывает карточку по ссылке из inline-результата. Возвращает false,
+// если параметр /start не ссылка на карточку
+func (h *Handler) startAttraction(msg *tgbotapi.Message, param string) bool {
+	if !strings.HasPrefix(param, startAttractionPrefix) {
+		return false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(param, startAttractionPrefix))
+	if err != nil {
+		return false
+	}
+
+	detail, ok := h.loadDetail(msg.Chat.ID, id)
+	if !ok {
+		return true
+	}
+	h.sendAttractionDetail(msg.Chat.ID, detail, h.detailKeyboard(msg.Chat.ID, userID(msg), "", detail))
+	return true
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"tg-bot/api"
+	"tg-bot/geo"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Допустимые границы расстояния для уведомлений, в метрах
+const (
+	minAlertDistance = 50
+	maxAlertDistance = 2000
+)
+
+// Как часто при трансляции геопозиции проверяются места рядом.
+// Telegram присылает новые координаты чаще, а за это время далеко не уйти
+const liveCheckInterval = 20 * time.Second
+
+// Сколько уведомлений отправляется за одну проверку, чтобы не засыпать чат
+const maxAlertsPerCheck = 3
+
+// liveKey трансляция геопозиции: сообщение с ней в чате
+type liveKey struct {
+	chatID    int64
+	messageID int
+}
+
+// liveSession состояние одной трансляции
+type liveSession struct {
+	userID    int64
+	expires   time.Time
+	lastCheck time.Time
+	// notified достопримечательности, о которых уже было уведомление
+	notified map[int]bool
+}
+
+// liveTracker активные трансляции геопозиции. Трансляции живут только
+// в памяти: после перезапуска бота пользователь начинает новую
+type liveTracker struct {
+	mu       sync.Mutex
+	sessions map[liveKey]*liveSession
+}
+
+func newLiveTracker() *liveTracker {
+	return &liveTracker{sessions: make(map[liveKey]*liveSession)}
+}
+
+// start начинает отслеживать трансляцию длительностью period секунд
+func (t *liveTracker) start(key liveKey, userID int64, period int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Заодно забываем трансляции, которые закончились без остановки
+	now := time.Now()
+	for k, s := range t.sessions {
+		if now.After(s.expires) {
+			delete(t.sessions, k)
+		}
+	}
+
+	t.sessions[key] = &liveSession{
+		userID:   userID,
+		expires:  now.Add(time.Duration(period) * time.Second),
+		notified: make(map[int]bool),
+	}
+}
+
+// stop прекращает отслеживать трансляцию. Возвращает false, если ее не было
+func (t *liveTracker) stop(key liveKey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.sessions[key]
+	delete(t.sessions, key)
+	return ok
+}
+
+// stopUser прекращает все трансляции пользователя в чате и возвращает их число
+func (t *liveTracker) stopUser(chatID, userID int64) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stopped := 0
+	for k, s := range t.sessions {
+		if k.chatID == chatID && s.userID == userID {
+			delete(t.sessions, k)
+			stopped++
+		}
+	}
+	return stopped
+}
+
+// due сообщает, пора ли проверить места рядом, и отмечает время проверки.
+// Возвращает пользователя, который ведет трансляцию
+func (t *liveTracker) due(key liveKey, now time.Time) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[key]
+	if !ok {
+		return 0, false
+	}
+	if now.After(s.expires) {
+		delete(t.sessions, key)
+		return 0, false
+	}
+	if now.Sub(s.lastCheck) < liveCheckInterval {
+		return 0, false
+	}
+	s.lastCheck = now
+	return s.userID, true
+}
+
+// markNotified отмечает достопримечательности как показанные и возвращает
+// те из attractions, о которых уведомления еще не было, не больше limit
+func (t *liveTracker) markNotified(key liveKey, attractions []models.Attraction, limit int) []models.Attraction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[key]
+	if !ok {
+		return nil
+	}
+	var fresh []models.Attraction
+	for _, attr := range attractions {
+		if len(fresh) == limit {
+			break
+		}
+		if !s.notified[attr.ID] {
+			s.notified[attr.ID] = true
+			fresh = append(fresh, attr)
+		}
+	}
+	return fresh
+}
+
+func (h *Handler) handleLive(msg *tgbotapi.Message, args string) {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "")
+
+	if args == "" {
+		reply.Text = fmt.Sprintf("🛰️ Включите трансляцию геопозиции (📎 → Геопозиция → Транслировать), "+
+			"и я сообщу, когда вы окажетесь в %s от достопримечательности.\n"+
+			"Изменить расстояние: /live 300\nОстановить уведомления: /stoplive",
+			formatRadius(h.store.Preferences(userID(msg)).AlertDistance))
+		h.bot.Send(reply)
+		return
+	}
+
+	distance, ok := parseMeters(args)
+	if !ok || distance < minAlertDistance || distance > maxAlertDistance {
+		reply.Text = fmt.Sprintf("❌ Расстояние должно быть числом от %s до %s, например: /live 300",
+			formatRadius(minAlertDistance), formatRadius(maxAlertDistance))
+		h.bot.Send(reply)
+		return
+	}
+
+	prefs := h.store.Preferences(userID(msg))
+	prefs.AlertDistance = distance
+	if err := h.store.SavePreferences(userID(msg), prefs); err != nil {
+		log.Printf("Ошибка сохранения настроек пользователя %d: %v", userID(msg), err)
+		reply.Text = "❌ Не удалось сохранить расстояние. Попробуйте позже."
+		h.bot.Send(reply)
+		return
+	}
+
+	reply.Text = fmt.Sprintf("✅ Сообщу о достопримечательностях ближе %s", formatRadius(distance))
+	h.bot.Send(reply)
+}
+
+func (h *Handler) handleStopLive(msg *tgbotapi.Message, _ string) {
+	text := "🛰️ Уведомления о местах рядом и так выключены."
+	if h.live.stopUser(msg.Chat.ID, userID(msg)) > 0 {
+		text = "🔕 Уведомления о местах рядом выключены. Трансляцию геопозиции можно остановить в Telegram."
+	}
+	h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+// начинает отслеживать трансляцию геопозиции из сообщения msg
+func (h *Handler) startLive(msg *tgbotapi.Message) {
+	from := userID(msg)
+	h.live.start(liveKey{msg.Chat.ID, msg.MessageID}, from, msg.Location.LivePeriod)
+
+	h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+		"🛰️ Слежу за трансляцией: сообщу, когда вы окажетесь в %s от достопримечательности.\nОстановить: /stoplive",
+		formatRadius(h.store.Preferences(from).AlertDistance))))
+}
+
+// обрабатывает новые координаты трансляции геопозиции
+func (h *Handler) HandleLiveLocation(update tgbotapi.Update) {
+	msg := update.EditedMessage
+	key := liveKey{msg.Chat.ID, msg.MessageID}
+
+	// У остановленной трансляции нет live_period
+	if msg.Location.LivePeriod == 0 {
+		if h.live.stop(key) {
+			h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "🛰️ Трансляция геопозиции закончилась, уведомления выключены."))
+		}
+		return
+	}
+
+	from, ok := h.live.due(key, time.Now())
+	if !ok {
+		return
+	}
+	distance := float64(h.store.Preferences(from).AlertDistance)
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	current := msg.Location
+	attractions, err := h.api.AttractionsByLocation(ctx, current.Latitude, current.Longitude,
+		api.RadiusFromMeters(int(distance)))
+	if err != nil {
+		// Пропускаем проверку, следующая будет с новыми координатами
+		log.Printf("Ошибка поиска мест рядом с трансляцией: %v", err)
+		return
+	}
+
+	sortByDistance(attractions, current)
+
+	var nearby []models.Attraction
+	for _, attr := range attractions {
+		if !hasCoordinates(attr) || distanceTo(current, attr) > distance {
+			break
+		}
+		nearby = append(nearby, attr)
+	}
+
+	for _, attr := range h.live.markNotified(key, nearby, maxAlertsPerCheck) {
+		meters := distanceTo(current, attr)
+		point, arrow := geo.Compass(geo.Bearing(current.Latitude, current.Longitude, attr.Latitude, attr.Longitude))
+
+		alert := tgbotapi.NewMessage(msg.Chat.ID, htmltext.Format("📍 Рядом: <b>%s</b>\n🚶 %s %s %s",
+			htmltext.Truncate(htmltext.Clean(attr.Name), 100), geo.FormatDistance(meters), arrow, point))
+		alert.ParseMode = "HTML"
+		alert.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("ℹ️ Подробнее", callbackData(actionAttraction, "", attr.ID)),
+		))
+		h.bot.Send(alert)
+	}
+}
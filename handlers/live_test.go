@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"testing"
+	"tg-bot/models"
+	"time"
+)
+
+func TestLiveTrackerDue(t *testing.T) {
+	key := liveKey{chatID: 1, messageID: 10}
+	start := time.Now()
+
+	tests := []struct {
+		name   string
+		after  time.Duration
+		wantOK bool
+	}{
+		{name: "first check", after: 0, wantOK: true},
+		{name: "too soon", after: liveCheckInterval / 2, wantOK: false},
+		{name: "after interval", after: liveCheckInterval + time.Second, wantOK: true},
+		{name: "right after previous", after: liveCheckInterval + 2*time.Second, wantOK: false},
+		{name: "expired", after: time.Hour, wantOK: false},
+		{name: "forgotten after expiry", after: time.Hour + liveCheckInterval, wantOK: false},
+	}
+
+	tracker := newLiveTracker()
+	tracker.start(key, 7, 30*60)
+	for _, tt := range tests {
+		userID, ok := tracker.due(key, start.Add(tt.after))
+		if ok != tt.wantOK {
+			t.Fatalf("%s: due() ok = %v, want %v", tt.name, ok, tt.wantOK)
+		}
+		if ok && userID != 7 {
+			t.Errorf("%s: due() user = %d, want 7", tt.name, userID)
+		}
+	}
+	if _, ok := tracker.sessions[key]; ok {
+		t.Error("expired session was not removed")
+	}
+}
+
+func TestLiveTrackerMarkNotified(t *testing.T) {
+	key := liveKey{chatID: 1, messageID: 10}
+	attractions := func(ids ...int) []models.Attraction {
+		list := make([]models.Attraction, len(ids))
+		for i, id := range ids {
+			list[i] = models.Attraction{ID: id}
+		}
+		return list
+	}
+
+	tests := []struct {
+		name  string
+		input []models.Attraction
+		limit int
+		want  []int
+	}{
+		{name: "limited per check", input: attractions(1, 2, 3, 4), limit: 2, want: []int{1, 2}},
+		{name: "rest on next check", input: attractions(1, 2, 3, 4), limit: 2, want: []int{3, 4}},
+		{name: "no repeats", input: attractions(1, 2, 3, 4), limit: 2, want: nil},
+		{name: "new place", input: attractions(2, 5), limit: 2, want: []int{5}},
+	}
+
+	tracker := newLiveTracker()
+	tracker.start(key, 7, 60)
+	for _, tt := range tests {
+		got := tracker.markNotified(key, tt.input, tt.limit)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: markNotified() = %v, want IDs %v", tt.name, got, tt.want)
+		}
+		for i, attr := range got {
+			if attr.ID != tt.want[i] {
+				t.Fatalf("%s: markNotified() = %v, want IDs %v", tt.name, got, tt.want)
+			}
+		}
+	}
+
+	if got := tracker.markNotified(liveKey{chatID: 2}, attractions(1), 3); got != nil {
+		t.Errorf("markNotified() for unknown session = %v", got)
+	}
+}
+
+func TestLiveTrackerStop(t *testing.T) {
+	tracker := newLiveTracker()
+	tracker.start(liveKey{chatID: 1, messageID: 10}, 7, 60)
+	tracker.start(liveKey{chatID: 1, messageID: 11}, 7, 60)
+	tracker.start(liveKey{chatID: 1, messageID: 12}, 8, 60)
+	tracker.start(liveKey{chatID: 2, messageID: 10}, 7, 60)
+
+	if n := tracker.stopUser(1, 7); n != 2 {
+		t.Errorf("stopUser(1, 7) = %d, want 2", n)
+	}
+	if n := tracker.stopUser(1, 7); n != 0 {
+		t.Errorf("second stopUser(1, 7) = %d, want 0", n)
+	}
+	if !tracker.stop(liveKey{chatID: 1, messageID: 12}) {
+		t.Error("stop() did not find another user's session")
+	}
+	if tracker.stop(liveKey{chatID: 1, messageID: 12}) {
+		t.Error("stop() found already stopped session")
+	}
+	if _, ok := tracker.due(liveKey{chatID: 2, messageID: 10}, time.Now()); !ok {
+		t.Error("session in another chat was stopped")
+	}
+}
+
+func TestLiveTrackerStartForgetsExpired(t *testing.T) {
+	tracker := newLiveTracker()
+	tracker.start(liveKey{chatID: 1, messageID: 10}, 7, 0)
+	time.Sleep(time.Millisecond)
+	tracker.start(liveKey{chatID: 1, messageID: 11}, 7, 60)
+
+	if _, ok := tracker.sessions[liveKey{chatID: 1, messageID: 10}]; ok {
+		t.Error("start() kept expired session")
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Допустимые границы радиуса поиска, в метрах
+const (
+	minRadius = 100
+	maxRadius = 50000
+)
+
+// radiusOptions радиусы, которые предлагаются кнопками, по возрастанию
+var radiusOptions = []int{500, 1000, 3000, 10000}
+
+// Действия кнопок радиуса
+const (
+	actionRadius = "r" // выбор радиуса, аргумент радиус в метрах
+	actionWider  = "w" // поиск шире, аргументы широта, долгота и радиус
+)
+
+func (h *Handler) handleRadius(msg *tgbotapi.Message, args string) {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "")
+
+	if args == "" {
+		current := h.store.Preferences(userID(msg)).Radius
+		reply.Text = fmt.Sprintf("📏 Текущий радиус поиска: %s.\nВыберите новый или укажите свой в метрах: /radius 2000",
+			formatRadius(current))
+		reply.ReplyMarkup = radiusKeyboard(current)
+		h.bot.Send(reply)
+		return
+	}
+
+	radius, ok := parseRadius(args)
+	if !ok {
+		reply.Text = fmt.Sprintf("❌ Радиус должен быть числом от %s до %s, например: /radius 3000",
+			formatRadius(minRadius), formatRadius(maxRadius))
+		h.bot.Send(reply)
+		return
+	}
+
+	if err := h.saveRadius(userID(msg), radius); err != nil {
+		reply.Text = "❌ Не удалось сохранить радиус. Попробуйте позже."
+		h.bot.Send(reply)
+		return
+	}
+
+	reply.Text = fmt.Sprintf("✅ Радиус поиска рядом: %s", formatRadius(radius))
+	h.bot.Send(reply)
+}
+
+// сохраняет радиус в настройках пользователя
+func (h *Handler) saveRadius(userID int64, radius int) error {
+	prefs := h.store.Preferences(userID)
+	prefs.Radius = radius
+	err := h.store.SavePreferences(userID, prefs)
+	if err != nil {
+		log.Printf("Ошибка сохранения настроек пользователя %d: %v", userID, err)
+	}
+	return err
+}
+
+// клавиатура выбора радиуса, текущий отмечен галочкой
+func radiusKeyboard(current int) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, radius := range radiusOptions {
+		label := formatRadius(radius)
+		if radius == current {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label,
+			callbackData(actionRadius, "", radius)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// сохраняет выбранный кнопкой радиус. Возвращает текст для ответа на callback
+func (h *Handler) handleRadiusCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	radius, ok := payload.intArg(0)
+	if !ok || radius < minRadius || radius > maxRadius {
+		return expiredText
+	}
+
+	if err := h.saveRadius(query.From.ID, radius); err != nil {
+		return "❌ Не удалось сохранить радиус. Попробуйте позже."
+	}
+
+	// Переносим галочку на выбранный радиус
+	h.editMessage(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID, query.Message.MessageID, radiusKeyboard(radius)))
+
+	h.bot.Send(tgbotapi.NewMessage(query.Message.Chat.ID,
+		fmt.Sprintf("✅ Радиус поиска рядом: %s. Отправьте геолокацию, чтобы искать.", formatRadius(radius))))
+	return ""
+}
+
+// nextRadius возвращает следующий по величине радиус из radiusOptions
+func nextRadius(radius int) (int, bool) {
+	for _, option := range radiusOptions {
+		if option > radius {
+			return option, true
+		}
+	}
+	return 0, false
+}
+
+// кнопка повторного поиска с большим радиусом, если он есть
+func widerSearchKeyboard(location *tgbotapi.Location, radius int) (tgbotapi.InlineKeyboardMarkup, bool) {
+	wider, ok := nextRadius(radius)
+	if !ok {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	data := callbackData(actionWider, "",
+		strconv.FormatFloat(location.Latitude, 'f', 5, 64),
+		strconv.FormatFloat(location.Longitude, 'f', 5, 64),
+		wider)
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔍 Искать шире: "+formatRadius(wider), data),
+	)), true
+}
+
+// повторяет поиск рядом с большим радиусом. Возвращает текст для ответа на callback
+func (h *Handler) handleWiderCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	if len(payload.Args) != 3 {
+		return expiredText
+	}
+	lat, errLat := strconv.ParseFloat(payload.Args[0], 64)
+	lon, errLon := strconv.ParseFloat(payload.Args[1], 64)
+	radius, ok := payload.intArg(2)
+	if errLat != nil || errLon != nil || !ok || radius < minRadius || radius > maxRadius {
+		return expiredText
+	}
+
+	// Убираем кнопку, чтобы поиск не запускали повторно
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	h.editMessage(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID, query.Message.MessageID, noButtons))
+
+	h.searchNearby(query.Message.Chat.ID, &tgbotapi.Location{Latitude: lat, Longitude: lon}, radius)
+	return ""
+}
+
+// parseRadius разбирает радиус вида "3000", "3000м" или "3 км"
+// и проверяет, что он в допустимых границах
+func parseRadius(s string) (int, bool) {
+	radius, ok := parseMeters(s)
+	if !ok || radius < minRadius || radius > maxRadius {
+		return 0, false
+	}
+	return radius, true
+}
+
+// parseMeters разбирает расстояние в метрах или километрах
+func parseMeters(s string) (int, bool) {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	s = strings.ReplaceAll(s, ",", ".")
+
+	multiplier := 1.0
+	for _, suffix := range []string{"км", "km"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix)
+			multiplier = 1000
+		}
+	}
+	for _, suffix := range []string{"м", "m"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(value * multiplier), true
+}
+
+// formatRadius выводит радиус в метрах или километрах
+func formatRadius(meters int) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%d м", meters)
+	}
+	if meters%1000 == 0 {
+		return fmt.Sprintf("%d км", meters/1000)
+	}
+	return fmt.Sprintf("%.1f км", float64(meters)/1000)
+}
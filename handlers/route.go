@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"tg-bot/geo"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+	"tg-bot/session"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок маршрута
+const (
+	actionRoute      = "rt" // добавить в маршрут или убрать из него, аргумент ID достопримечательности
+	actionRouteClear = "rx" // очистить маршрут
+)
+
+func (h *Handler) handleRoute(msg *tgbotapi.Message, _ string) {
+	route := h.store.Route(userID(msg))
+	stops := routeStops(route)
+	if len(stops) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID,
+			"🚶 Маршрут пока пуст. Откройте карточку достопримечательности и нажмите «🚶 В маршрут»."))
+		return
+	}
+	if route.Start == nil && len(stops) < 2 {
+		// Из одной точки без геолокации маршрут не построить
+		h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"🚶 В маршруте одно место: %s.\n📍 Отправьте геолокацию, чтобы проложить путь от вас, или добавьте еще достопримечательности.",
+			htmltext.Truncate(stops[0].Name, 100))))
+		return
+	}
+
+	text, keyboard := formatRoute(route.Start, stops)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = "HTML"
+	reply.ReplyMarkup = keyboard
+	h.bot.Send(reply)
+}
+
+// updateRoute читает маршрут пользователя, изменяет его через update и сохраняет.
+// Блокировка не дает двум нажатиям одновременно перезаписать маршрут
+func (h *Handler) updateRoute(userID int64, update func(route *session.Route) error) (session.Route, error) {
+	unlock := h.store.LockUser(userID)
+	defer unlock()
+
+	route := h.store.Route(userID)
+	if err := update(&route); err != nil {
+		return route, err
+	}
+	if err := h.store.SaveRoute(userID, route); err != nil {
+		log.Printf("Ошибка сохранения маршрута пользователя %d: %v", userID, err)
+		return route, err
+	}
+	return route, nil
+}
+
+// запоминает геолокацию пользователя как начало маршрута
+func (h *Handler) saveRouteStart(userID int64, location *tgbotapi.Location) {
+	h.updateRoute(userID, func(route *session.Route) error {
+		start := *location
+		route.Start = &start
+		return nil
+	})
+}
+
+// кнопка добавления в маршрут или удаления из него
+func routeButton(searchID string, attractionID int, inRoute bool) tgbotapi.InlineKeyboardButton {
+	label := "🚶 В маршрут"
+	if inRoute {
+		label = "➖ Убрать из маршрута"
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, callbackData(actionRoute, searchID, attractionID))
+}
+
+// добавляет достопримечательность в маршрут или убирает ее оттуда
+// и обновляет кнопку в карточке. Возвращает текст для ответа на callback
+func (h *Handler) handleRouteCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	id, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+	chatID := query.Message.Chat.ID
+	userID := query.From.ID
+
+	detail, ok := h.loadDetail(chatID, id)
+	if !ok {
+		return ""
+	}
+
+	added := false
+	route, err := h.updateRoute(userID, func(route *session.Route) error {
+		if route.HasStop(id) {
+			route.RemoveStop(id)
+			return nil
+		}
+		added = true
+		return route.AddStop(attractionSummary(detail))
+	})
+	switch {
+	case errors.Is(err, session.ErrRouteFull):
+		return fmt.Sprintf("🚶 В маршруте уже %d мест. Уберите что-нибудь, чтобы добавить новое.", session.MaxRouteStops)
+	case err != nil:
+		return "❌ Не удалось сохранить маршрут. Попробуйте позже."
+	}
+
+	h.editMessage(tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID,
+		h.detailKeyboard(chatID, userID, payload.SearchID, detail)))
+
+	if added {
+		return fmt.Sprintf("🚶 Добавлено в маршрут (%d из %d). Показать маршрут: /route",
+			len(route.Stops), session.MaxRouteStops)
+	}
+	return ""
+}
+
+// очищает маршрут. Возвращает текст для ответа на callback
+func (h *Handler) handleRouteClearCallback(query *tgbotapi.CallbackQuery) string {
+	_, err := h.updateRoute(query.From.ID, func(route *session.Route) error {
+		// Геолокация остается началом следующего маршрута
+		route.Stops = nil
+		return nil
+	})
+	if err != nil {
+		return "❌ Не удалось очистить маршрут. Попробуйте позже."
+	}
+
+	h.editMessage(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		"🗑️ Маршрут очищен."))
+	return ""
+}
+
+// routeStops остановки маршрута, которые можно показать на карте
+func routeStops(route session.Route) []models.Attraction {
+	var stops []models.Attraction
+	for _, stop := range route.Stops {
+		if hasCoordinates(stop) {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
+
+// formatRoute упорядочивает остановки и возвращает описание маршрута
+// от location с расстояниями и клавиатуру со ссылками на карты.
+// Без location остановок должно быть не меньше двух
+func formatRoute(location *tgbotapi.Location, stops []models.Attraction) (string, tgbotapi.InlineKeyboardMarkup) {
+	// Без геолокации маршрут начинается с первой добавленной остановки
+	var start geo.Point
+	var startName string
+	if location != nil {
+		start = geo.Point{Lat: location.Latitude, Lon: location.Longitude}
+		startName = "ваша геолокация"
+	} else {
+		start = geo.Point{Lat: stops[0].Latitude, Lon: stops[0].Longitude}
+		startName = stops[0].Name
+		stops = stops[1:]
+	}
+
+	points := make([]geo.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = geo.Point{Lat: stop.Latitude, Lon: stop.Longitude}
+	}
+	order := geo.OrderRoute(start, points)
+
+	var builder strings.Builder
+	builder.WriteString("🚶 <b>Пешеходный маршрут</b>\n\n")
+	builder.WriteString(htmltext.Format("🏁 Старт: %s\n", htmltext.Truncate(startName, 100)))
+
+	path := []geo.Point{start}
+	prev := start
+	for n, i := range order {
+		leg := geo.Distance(prev.Lat, prev.Lon, points[i].Lat, points[i].Lon)
+		builder.WriteString(htmltext.Format("%d. %s — %s, ~%s\n", n+1, htmltext.Truncate(stops[i].Name, 100),
+			geo.FormatDistance(leg), geo.FormatDuration(geo.WalkingMinutes(leg))))
+		path = append(path, points[i])
+		prev = points[i]
+	}
+
+	total := geo.RouteLength(start, points, order)
+	builder.WriteString(htmltext.Format("\n📏 Всего: %s, ~%s пешком\n",
+		geo.FormatDistance(total), geo.FormatDuration(geo.WalkingMinutes(total))))
+	if location == nil {
+		builder.WriteString("\n📍 Отправьте геолокацию, чтобы маршрут начинался от вас.\n")
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("Яндекс Карты", yandexRouteURL(path)),
+			tgbotapi.NewInlineKeyboardButtonURL("Google Maps", googleRouteURL(path)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑️ Очистить маршрут", callbackData(actionRouteClear, "")),
+		),
+	)
+	return builder.String(), keyboard
+}
+
+// yandexRouteURL ссылка на пешеходный маршрут через точки path в Яндекс Картах
+func yandexRouteURL(path []geo.Point) string {
+	points := make([]string, len(path))
+	for i, p := range path {
+		points[i] = formatPoint(p)
+	}
+	return "https://yandex.ru/maps/?" + url.Values{
+		"rtext": {strings.Join(points, "~")},
+		"rtt":   {"pd"},
+	}.Encode()
+}
+
+// googleRouteURL ссылка на пешеходный маршрут через точки path в Google Maps
+func googleRouteURL(path []geo.Point) string {
+	params := url.Values{
+		"api":         {"1"},
+		"origin":      {formatPoint(path[0])},
+		"destination": {formatPoint(path[len(path)-1])},
+		"travelmode":  {"walking"},
+	}
+	if len(path) > 2 {
+		waypoints := make([]string, 0, len(path)-2)
+		for _, p := range path[1 : len(path)-1] {
+			waypoints = append(waypoints, formatPoint(p))
+		}
+		params.Set("waypoints", strings.Join(waypoints, "|"))
+	}
+	return "https://www.google.com/maps/dir/?" + params.Encode()
+}
+
+func formatPoint(p geo.Point) string {
+	return fmt.Sprintf("%.6f,%.6f", p.Lat, p.Lon)
+}
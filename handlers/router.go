@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// command команда бота
+type command struct {
+	Name        string
+	Description string
+	handle      func(h *Handler, msg *tgbotapi.Message, args string)
+}
+
+// commands команды бота в том порядке, в котором они показываются в меню
+var commands []command
+
+// Таблица заполняется в init: /help сам читает commands,
+// и инициализация при объявлении дала бы цикл
+func init() {
+	commands = []command{
+		{"start", "Начать работу с ботом", (*Handler).handleStart},
+		{"help", "Список команд", (*Handler).handleHelp},
+		{"city", "Найти достопримечательности в городе", (*Handler).handleCityCommand},
+		{"near", "Найти достопримечательности рядом", (*Handler).handleNear},
+		{"favorites", "Избранные достопримечательности", (*Handler).handleFavorites},
+		{"route", "Пешеходный маршрут по выбранным местам", (*Handler).handleRoute},
+		{"live", "Уведомления о местах рядом во время трансляции", (*Handler).handleLive},
+		{"stoplive", "Выключить уведомления о местах рядом", (*Handler).handleStopLive},
+		{"radius", "Радиус поиска рядом", (*Handler).handleRadius},
+		{"cancel", "Сбросить текущий поиск", (*Handler).handleCancel},
+	}
+}
+
+// RegisterCommands регистрирует команды в Telegram, чтобы они
+// появились в меню клиента
+func (h *Handler) RegisterCommands() error {
+	botCommands := make([]tgbotapi.BotCommand, 0, len(commands))
+	for _, cmd := range commands {
+		botCommands = append(botCommands, tgbotapi.BotCommand{
+			Command:     cmd.Name,
+			Description: cmd.Description,
+		})
+	}
+	_, err := h.bot.Request(tgbotapi.NewSetMyCommands(botCommands...))
+	return err
+}
+
+// Route передает обновление подходящему обработчику
+func (h *Handler) Route(update tgbotapi.Update) {
+	switch {
+	case update.Message != nil:
+		h.routeMessage(update)
+	case update.EditedMessage != nil:
+		// Новые координаты трансляции приходят правкой сообщения
+		if update.EditedMessage.Location != nil {
+			h.HandleLiveLocation(update)
+		}
+	case update.CallbackQuery != nil:
+		h.HandleCallback(update)
+	case update.InlineQuery != nil:
+		h.HandleInlineQuery(update)
+	}
+}
+
+func (h *Handler) routeMessage(update tgbotapi.Update) {
+	msg := update.Message
+
+	switch {
+	case msg.Location != nil:
+		h.HandleLocation(update)
+	case msg.IsCommand():
+		h.handleCommand(msg)
+	case msg.Text != "":
+		h.HandleCity(update)
+	}
+}
+
+// handleCommand разбирает команду вида /city@botname Ярославль
+func (h *Handler) handleCommand(msg *tgbotapi.Message) {
+	name := msg.CommandWithAt()
+	if at := strings.Index(name, "@"); at >= 0 {
+		// В группах команда может быть адресована другому боту
+		if !strings.EqualFold(name[at+1:], h.bot.Self.UserName) {
+			return
+		}
+		name = name[:at]
+	}
+	name = strings.ToLower(name)
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			cmd.handle(h, msg, args)
+			return
+		}
+	}
+
+	h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "🤔 Неизвестная команда. Список команд: /help"))
+}
+
+func (h *Handler) handleStart(msg *tgbotapi.Message, args string) {
+	// Ссылка «Подробнее в боте» из inline-результата
+	if h.startAttraction(msg, args) {
+		return
+	}
+	h.HandleMessage(tgbotapi.Update{Message: msg})
+}
+
+func (h *Handler) handleHelp(msg *tgbotapi.Message, _ string) {
+	var builder strings.Builder
+	builder.WriteString("Что я умею:\n\n")
+	for _, cmd := range commands {
+		builder.WriteString(fmt.Sprintf("/%s — %s\n", cmd.Name, cmd.Description))
+	}
+	builder.WriteString("\nМожно просто написать название города или отправить геолокацию.")
+	builder.WriteString("\nИскать в городе по названию или слову: Ярославль: музей")
+	builder.WriteString(fmt.Sprintf("\nВ любом чате: @%s Ярославль собор", h.bot.Self.UserName))
+
+	h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, builder.String()))
+}
+
+func (h *Handler) handleCityCommand(msg *tgbotapi.Message, args string) {
+	if args == "" {
+		h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "🏙️ Напишите название города, например: /city Ярославль"))
+		return
+	}
+	h.searchCity(msg.Chat.ID, args)
+}
+
+func (h *Handler) handleNear(msg *tgbotapi.Message, _ string) {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+		"📍 Отправьте геолокацию кнопкой ниже, и я найду достопримечательности в радиусе %s.",
+		formatRadius(h.store.Preferences(userID(msg)).Radius)))
+	reply.ReplyMarkup = locationKeyboard()
+	h.bot.Send(reply)
+}
+
+func (h *Handler) handleCancel(msg *tgbotapi.Message, _ string) {
+	unlock := h.store.Lock(msg.Chat.ID)
+	err := h.store.Delete(msg.Chat.ID)
+	unlock()
+	if err != nil {
+		log.Printf("Ошибка удаления состояния чата %d: %v", msg.Chat.ID, err)
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "🧹 Поиск сброшен. Отправьте название города или геолокацию, чтобы начать заново.")
+	reply.ReplyMarkup = locationKeyboard()
+	h.bot.Send(reply)
+}
+
+// userID возвращает ID автора сообщения. У сообщений от имени канала
+// автора нет, тогда настройки привязываются к чату
+func userID(msg *tgbotapi.Message) int64 {
+	if msg.From != nil {
+		return msg.From.ID
+	}
+	return msg.Chat.ID
+}
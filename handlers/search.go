@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+	"tg-bot/htmltext"
+	"tg-bot/models"
+	"tg-bot/session"
+	"tg-bot/textsearch"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действие кнопки поиска по результатам. Без аргументов кнопка просит
+// ввести запрос, с аргументом searchReset сбрасывает его
+const actionSearch = "q"
+
+const searchReset = "x"
+
+// Вес совпадений в названии выше, чем в описании и адресе
+const (
+	nameWeight        = 3
+	descriptionWeight = 1
+	addressWeight     = 1
+)
+
+// attractionScore насколько достопримечательность подходит под запрос
+func attractionScore(query textsearch.Query, attr models.Attraction) float64 {
+	return query.Score(
+		textsearch.Field{Text: attr.Name, Weight: nameWeight},
+		textsearch.Field{Text: attr.Description, Weight: descriptionWeight},
+		textsearch.Field{Text: attr.Address, Weight: addressWeight},
+	)
+}
+
+// splitCityQuery разбирает запрос вида «Ярославль: музей» на город
+// и слова для поиска среди его достопримечательностей
+func splitCityQuery(text string) (city, query string) {
+	city, query, found := strings.Cut(text, ":")
+	if !found {
+		return text, ""
+	}
+	return strings.TrimSpace(city), strings.TrimSpace(query)
+}
+
+// кнопка поиска по результатам или сброса запроса
+func searchButton(state *session.PaginationState) tgbotapi.InlineKeyboardButton {
+	if state.Query != "" {
+		return tgbotapi.NewInlineKeyboardButtonData("✖️ Сбросить поиск",
+			callbackData(actionSearch, state.SearchID, searchReset))
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("🔍 Поиск в результатах",
+		callbackData(actionSearch, state.SearchID))
+}
+
+// просит ввести запрос для поиска по результатам или сбрасывает его.
+// Возвращает текст для ответа на callback
+func (h *Handler) handleSearchCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	message := query.Message
+	chatID := message.Chat.ID
+
+	unlock := h.store.Lock(chatID)
+	defer unlock()
+
+	state, exists := h.store.Search(chatID, payload.SearchID)
+	if !exists {
+		return expiredText
+	}
+
+	if len(payload.Args) == 1 && payload.Args[0] == searchReset {
+		state.Query = ""
+		clearAwaitingQuery(state)
+		h.sendAttractionsPage(chatID, message.MessageID, state, 0)
+		return ""
+	}
+
+	prompt := tgbotapi.NewMessage(chatID, "🔍 Что найти среди результатов? Например: музей, собор, набережная")
+	prompt.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply:            true,
+		InputFieldPlaceholder: "музей",
+		Selective:             true,
+	}
+	sent, err := h.bot.Send(prompt)
+	if err != nil {
+		log.Printf("Ошибка отправки запроса поиска в чат %d: %v", chatID, err)
+		return "❌ Не удалось начать поиск. Попробуйте позже."
+	}
+
+	// Поиск становится текущим, и запросом будет ответ на подсказку
+	// или следующее сообщение нажавшего кнопку
+	state.AwaitingQuery = true
+	state.QueryFrom = query.From.ID
+	state.QueryPrompt = sent.MessageID
+	if err := h.store.Put(chatID, state); err != nil {
+		log.Printf("Ошибка сохранения состояния чата %d: %v", chatID, err)
+		return "❌ Не удалось начать поиск. Попробуйте позже."
+	}
+	return ""
+}
+
+// clearAwaitingQuery отменяет ожидание запроса поиска
+func clearAwaitingQuery(state *session.PaginationState) {
+	state.AwaitingQuery = false
+	state.QueryFrom = 0
+	state.QueryPrompt = 0
+}
+
+// isSearchQuery сообщает, что msg отвечает на просьбу ввести запрос:
+// это ответ на подсказку или сообщение того, кто нажал кнопку
+func isSearchQuery(state *session.PaginationState, msg *tgbotapi.Message) bool {
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.MessageID == state.QueryPrompt {
+		return true
+	}
+	return msg.From != nil && msg.From.ID == state.QueryFrom
+}
+
+// ищет по результатам текущего поиска, если чат ждет запрос.
+// Возвращает false, если сообщение не запрос
+func (h *Handler) searchInResults(msg *tgbotapi.Message) bool {
+	chatID := msg.Chat.ID
+
+	unlock := h.store.Lock(chatID)
+	defer unlock()
+
+	state, exists := h.store.Get(chatID)
+	if !exists || !state.AwaitingQuery {
+		return false
+	}
+
+	if !isSearchQuery(state, msg) {
+		// Кто-то другой написал в чат: это обычный запрос города,
+		// а поиск по результатам можно начать заново кнопкой
+		clearAwaitingQuery(state)
+		if err := h.store.Put(chatID, state); err != nil {
+			log.Printf("Ошибка сохранения состояния чата %d: %v", chatID, err)
+		}
+		return false
+	}
+
+	clearAwaitingQuery(state)
+	state.Query = strings.TrimSpace(htmltext.Clean(msg.Text))
+	h.sendAttractionsPage(chatID, 0, state, 0)
+	return true
+}
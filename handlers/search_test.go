@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"testing"
+	"tg-bot/session"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestIsSearchQuery(t *testing.T) {
+	state := &session.PaginationState{AwaitingQuery: true, QueryFrom: 1, QueryPrompt: 100}
+
+	tests := []struct {
+		name string
+		msg  *tgbotapi.Message
+		want bool
+	}{
+		{
+			name: "from the user who pressed the button",
+			msg:  &tgbotapi.Message{From: &tgbotapi.User{ID: 1}},
+			want: true,
+		},
+		{
+			name: "reply to the prompt",
+			msg: &tgbotapi.Message{
+				From:           &tgbotapi.User{ID: 2},
+				ReplyToMessage: &tgbotapi.Message{MessageID: 100},
+			},
+			want: true,
+		},
+		{
+			name: "another user",
+			msg:  &tgbotapi.Message{From: &tgbotapi.User{ID: 2}},
+		},
+		{
+			name: "reply to another message",
+			msg: &tgbotapi.Message{
+				From:           &tgbotapi.User{ID: 2},
+				ReplyToMessage: &tgbotapi.Message{MessageID: 99},
+			},
+		},
+		{
+			name: "channel post without sender",
+			msg:  &tgbotapi.Message{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSearchQuery(state, tt.msg); got != tt.want {
+				t.Errorf("isSearchQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCityQuery(t *testing.T) {
+	tests := []struct {
+		text, city, query string
+	}{
+		{"Ярославль", "Ярославль", ""},
+		{"Ярославль: музей", "Ярославль", "музей"},
+		{" Ярославль :  храмы  ", "Ярославль", "храмы"},
+		{"Ярославль:", "Ярославль", ""},
+	}
+
+	for _, tt := range tests {
+		city, query := splitCityQuery(tt.text)
+		if city != tt.city || query != tt.query {
+			t.Errorf("splitCityQuery(%q) = %q, %q, want %q, %q", tt.text, city, query, tt.city, tt.query)
+		}
+	}
+}
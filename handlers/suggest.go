@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"tg-bot/cities"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действие кнопки подсказки, аргумент индекс города в списке известных
+const actionCity = "c"
+
+// Сколько похожих городов предлагается, если город не найден
+const maxCitySuggestions = 3
+
+// кнопки с известными городами, похожими на city. Сам city не предлагается
+func citySuggestions(city string) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, name := range cities.Suggest(city, maxCitySuggestions+1) {
+		i, ok := cities.Index(name)
+		if !ok || name == city || len(rows) == maxCitySuggestions {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏙️ "+name, callbackData(actionCity, "", i)),
+		))
+	}
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
+
+// ищет достопримечательности в городе из подсказки. Возвращает текст для ответа на callback
+func (h *Handler) handleCityCallback(query *tgbotapi.CallbackQuery, payload callbackPayload) string {
+	i, ok := payload.intArg(0)
+	if !ok {
+		return expiredText
+	}
+	city, ok := cities.Name(i)
+	if !ok {
+		return expiredText
+	}
+
+	// Убираем подсказки, чтобы не искать повторно
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	h.editMessage(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID, query.Message.MessageID, noButtons))
+
+	h.searchCity(query.Message.Chat.ID, city)
+	return ""
+}
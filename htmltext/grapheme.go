@@ -0,0 +1,91 @@
+package htmltext
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner = '\u200d'
+	keycap          = '\u20e3'
+)
+
+// graphemes делит строку на графемы — то, что пользователь видит как один
+// символ. Это упрощенная версия правил Unicode (UAX #29), которой хватает
+// для кириллицы, диакритики и эмодзи: к предыдущему символу присоединяются
+// комбинируемые знаки, селекторы вариантов, модификаторы цвета кожи,
+// теги флагов и все, что идет после соединителя нулевой ширины.
+// Региональные индикаторы объединяются в пары
+func graphemes(s string) []string {
+	var clusters []string
+	start := 0
+	var prev rune = -1
+	regional := 0
+
+	for i := 0; i < len(s); {
+		r, size := decodeRune(s[i:])
+
+		join := false
+		switch {
+		case prev < 0:
+		case prev == '\r' && r == '\n':
+			join = true
+		case isExtend(r):
+			join = true
+		case prev == zeroWidthJoiner:
+			join = true
+		case isRegionalIndicator(r) && regional%2 == 1:
+			join = true
+		}
+
+		if !join && i > start {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+
+		if isRegionalIndicator(r) {
+			regional++
+		} else if !isExtend(r) {
+			regional = 0
+		}
+		prev = r
+		i += size
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// isExtend символы, которые не бывают самостоятельными
+// и относятся к предыдущей графеме
+func isExtend(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == zeroWidthJoiner || r == keycap:
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f: // селекторы вариантов
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // модификаторы цвета кожи
+		return true
+	case r >= 0xe0020 && r <= 0xe007f: // теги флагов регионов
+		return true
+	case r >= 0xe0100 && r <= 0xe01ef: // дополнительные селекторы вариантов
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// decodeRune декодирует руну, считая некорректный байт отдельным символом
+func decodeRune(s string) (rune, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return utf8.RuneError, 1
+	}
+	return r, size
+}
@@ -0,0 +1,186 @@
+// Package htmltext готовит текст для сообщений Telegram с parse_mode HTML:
+// экранирует данные, считает длину так же, как Telegram, и обрезает
+// текст, не разрывая символы и составные эмодзи
+package htmltext
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Ellipsis добавляется к обрезанному тексту
+const Ellipsis = "…"
+
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// Clean удаляет из строки байты, которые не являются корректным UTF-8
+func Clean(s string) string {
+	return strings.ToValidUTF8(s, "")
+}
+
+// Escape очищает строку и заменяет символы, которые Telegram
+// разбирает как разметку. Подходит и для значений атрибутов
+func Escape(s string) string {
+	return escaper.Replace(Clean(s))
+}
+
+// Format работает как fmt.Sprintf, но экранирует строковые аргументы.
+// Разметка допустима только в самом format
+func Format(format string, args ...interface{}) string {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			escaped[i] = Escape(v)
+		case fmt.Stringer:
+			escaped[i] = Escape(v.String())
+		default:
+			escaped[i] = arg
+		}
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+// Length длина текста в символах UTF-16: так Telegram считает
+// ограничения на длину сообщений и подписей
+func Length(s string) int {
+	length := 0
+	for _, r := range s {
+		length += runeLength(r)
+	}
+	return length
+}
+
+// VisibleLength длина HTML-текста после разбора разметки: теги
+// не учитываются, а каждая сущность вроде &amp; считается одним символом
+func VisibleLength(html string) int {
+	length := 0
+	for i := 0; i < len(html); {
+		switch html[i] {
+		case '<':
+			end := strings.IndexByte(html[i:], '>')
+			if end < 0 {
+				return length + Length(html[i:])
+			}
+			i += end + 1
+			continue
+		case '&':
+			if end := strings.IndexByte(html[i:], ';'); end > 0 && end <= 10 {
+				length++
+				i += end + 1
+				continue
+			}
+		}
+		r, size := decodeRune(html[i:])
+		length += runeLength(r)
+		i += size
+	}
+	return length
+}
+
+// Truncate обрезает текст до limit символов UTF-16 вместе с многоточием.
+// Текст режется только на границе графем, поэтому буквы с диакритикой,
+// флаги и составные эмодзи не разрываются
+func Truncate(s string, limit int) string {
+	s = Clean(s)
+	if Length(s) <= limit {
+		return s
+	}
+
+	budget := limit - Length(Ellipsis)
+	var builder strings.Builder
+	length := 0
+	for _, cluster := range graphemes(s) {
+		size := Length(cluster)
+		if length+size > budget {
+			break
+		}
+		builder.WriteString(cluster)
+		length += size
+	}
+	return strings.TrimRightFunc(builder.String(), unicode.IsSpace) + Ellipsis
+}
+
+// Split делит текст без разметки на части не длиннее limit символов UTF-16.
+// Резать старается по абзацам, затем по предложениям и словам,
+// и никогда не разрывает графемы
+func Split(s string, limit int) []string {
+	var parts []string
+	clusters := graphemes(strings.TrimSpace(Clean(s)))
+
+	for len(clusters) > 0 {
+		// Самый длинный префикс, который помещается в limit
+		end, length := 0, 0
+		for end < len(clusters) {
+			size := Length(clusters[end])
+			if length+size > limit {
+				break
+			}
+			length += size
+			end++
+		}
+		if end == len(clusters) {
+			parts = append(parts, strings.Join(clusters, ""))
+			break
+		}
+		if end == 0 {
+			// Графема длиннее limit, отдаем ее целиком
+			end = 1
+		}
+
+		cut := lastBreak(clusters[:end])
+		if cut == 0 {
+			cut = end
+		}
+		parts = append(parts, strings.TrimSpace(strings.Join(clusters[:cut], "")))
+		clusters = trimLeftSpace(clusters[cut:])
+	}
+	return parts
+}
+
+// lastBreak ищет последнее удобное место для разреза: конец абзаца,
+// конец предложения или пробел во второй половине текста.
+// Возвращает 0, если такого места нет
+func lastBreak(clusters []string) int {
+	half := len(clusters) / 2
+	for _, isBreak := range []func(i int) bool{
+		func(i int) bool { return clusters[i] == "\n" },
+		func(i int) bool {
+			return strings.ContainsAny(clusters[i], ".!?…") && i+1 < len(clusters) && isSpace(clusters[i+1])
+		},
+		func(i int) bool { return isSpace(clusters[i]) },
+	} {
+		for i := len(clusters) - 1; i >= half; i-- {
+			if isBreak(i) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+func trimLeftSpace(clusters []string) []string {
+	for len(clusters) > 0 && isSpace(clusters[0]) {
+		clusters = clusters[1:]
+	}
+	return clusters
+}
+
+func isSpace(cluster string) bool {
+	return strings.TrimSpace(cluster) == ""
+}
+
+// runeLength сколько символов UTF-16 занимает руна
+func runeLength(r rune) int {
+	if r >= 0x10000 && r <= unicode.MaxRune && !utf16.IsSurrogate(r) {
+		return 2
+	}
+	return 1
+}
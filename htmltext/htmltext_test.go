@@ -0,0 +1,149 @@
+package htmltext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	family  = "\U0001F468\u200D\U0001F469\u200D\U0001F467" // ZWJ-последовательность, 8 символов UTF-16
+	flagRU  = "\U0001F1F7\U0001F1FA"                       // флаг из двух региональных индикаторов
+	flagUA  = "\U0001F1FA\U0001F1E6"
+	thumbs  = "\U0001F44D\U0001F3FD" // эмодзи с цветом кожи
+	keycap1 = "1\uFE0F\u20E3"        // цифра в рамке
+	eAcute  = "e\u0301"              // буква с комбинируемым ударением
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{name: "empty", in: "", want: nil},
+		{name: "cyrillic", in: "Ярославль", want: strings.Split("Ярославль", "")},
+		{name: "combining mark", in: eAcute + "x", want: []string{eAcute, "x"}},
+		{name: "surrogate pair", in: "a\U0001F600b", want: []string{"a", "\U0001F600", "b"}},
+		{name: "zwj emoji", in: family + "!", want: []string{family, "!"}},
+		{name: "skin tone", in: thumbs + thumbs, want: []string{thumbs, thumbs}},
+		{name: "flags", in: flagRU + flagUA, want: []string{flagRU, flagUA}},
+		{name: "keycap", in: keycap1 + "2", want: []string{keycap1, "2"}},
+		{name: "crlf", in: "a\r\nb", want: []string{"a", "\r\n", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graphemes(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("graphemes(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"Ярославль", 9},
+		{"\U0001F600", 2},
+		{family, 8},
+		{flagRU, 4},
+	}
+
+	for _, tt := range tests {
+		if got := Length(tt.in); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVisibleLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"plain", 5},
+		{"<b>Кремль</b>", 6},
+		{`<a href="https://example.com/?a=1&amp;b=2">ссылка</a>`, 6},
+		{"A &amp; B", 5},
+		{"&lt;тег&gt;", 5},
+		{"<b>" + family + "</b>", 8},
+		{"a & b", 5},
+		{"text <unclosed", 14},
+	}
+
+	for _, tt := range tests {
+		if got := VisibleLength(tt.in); got != tt.want {
+			t.Errorf("VisibleLength(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  string
+	}{
+		{name: "fits", in: "Кремль", limit: 6, want: "Кремль"},
+		{name: "cut", in: "Спасо-Преображенский", limit: 6, want: "Спасо…"},
+		{name: "trailing space", in: "Дом на набережной", limit: 5, want: "Дом…"},
+		{name: "surrogate pair", in: "ab\U0001F600cd", limit: 4, want: "ab…"},
+		{name: "zwj emoji", in: "a" + family + "b", limit: 9, want: "a…"},
+		{name: "flag", in: flagRU + flagUA, limit: 6, want: flagRU + "…"},
+		{name: "combining mark", in: "ab" + eAcute + "cd", limit: 4, want: "ab…"},
+		{name: "grapheme longer than limit", in: family + family, limit: 5, want: "…"},
+		{name: "invalid utf8", in: "ab\xffcd", limit: 10, want: "abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.in, tt.limit)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+			}
+			if Length(got) > tt.limit {
+				t.Errorf("Truncate(%q, %d) length %d exceeds limit", tt.in, tt.limit, Length(got))
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  []string
+	}{
+		{name: "fits", in: "  один абзац  ", limit: 20, want: []string{"один абзац"}},
+		{name: "paragraph", in: "первый абзац\nвторой", limit: 15, want: []string{"первый абзац", "второй"}},
+		{name: "sentence", in: "Раз два. Три четыре", limit: 12, want: []string{"Раз два.", "Три четыре"}},
+		{name: "word", in: "один два три", limit: 9, want: []string{"один два", "три"}},
+		{name: "no break", in: "абвгдеж", limit: 3, want: []string{"абв", "где", "ж"}},
+		{name: "surrogate pairs", in: "\U0001F600\U0001F600\U0001F600", limit: 3, want: []string{"\U0001F600", "\U0001F600", "\U0001F600"}},
+		{name: "grapheme longer than limit", in: family + "ab", limit: 4, want: []string{family, "ab"}},
+		{name: "empty", in: "   ", limit: 10, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.in, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	got := Format("<b>%s</b> %d", "<Кремль & «сад»>", 5)
+	want := "<b>&lt;Кремль &amp; «сад»&gt;</b> 5"
+	if got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+}
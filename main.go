@@ -1,14 +1,32 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"tg-bot/api"
+	"tg-bot/dispatcher"
 	"tg-bot/handlers"
+	"tg-bot/session"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 )
 
+// Сколько хранится состояние пагинации чата
+const stateTTL = 24 * time.Hour
+
+// Число воркеров, обрабатывающих обновления, по умолчанию
+const defaultWorkers = 8
+
+// Сколько ждать завершения обработки при остановке
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	// Загружаем переменные окружения
 	err := godotenv.Load()
@@ -25,26 +43,133 @@ func main() {
 	bot.Debug = true
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
-	// Настраиваем канал обновлений
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := bot.GetUpdatesChan(u)
-
-	// Обрабатываем обновления
-	for update := range updates {
-		if update.Message != nil {
-			if update.Message.Location != nil {
-				go handlers.HandleLocation(bot, update)
-			} else if update.Message.Text != "" {
-				if update.Message.Text == "/start" {
-					go handlers.HandleMessage(bot, update)
-				} else {
-					go handlers.HandleCity(bot, update)
-				}
+	// Создаем клиент API, адрес можно переопределить для стейджинга
+	var apiOptions []api.Option
+	if baseURL := os.Getenv("API_BASE_URL"); baseURL != "" {
+		apiOptions = append(apiOptions, api.WithBaseURL(baseURL))
+	}
+	if maxPages, err := strconv.Atoi(os.Getenv("API_MAX_PAGES")); err == nil {
+		apiOptions = append(apiOptions, api.WithMaxPages(maxPages))
+	}
+	// Кэшируем ответы API, чтобы повторные запросы не уходили на сервер
+	service := api.NewCachedService(api.NewClient(apiOptions...), api.DefaultCacheConfig)
+	// Состояния пагинации живут сутки с момента последнего обращения.
+	// Если задан STATE_DB_PATH, они, как и настройки, избранное и маршруты,
+	// переживают перезапуск бота
+	var store session.Store
+	if path := os.Getenv("STATE_DB_PATH"); path != "" {
+		store, err = session.OpenBoltStore(path, stateTTL)
+		if err != nil {
+			log.Fatalf("Error opening state database: %v", err)
+		}
+	} else {
+		log.Println("STATE_DB_PATH is not set: searches, settings, favorites and routes are kept in memory and lost on restart")
+		store = session.NewMemoryStore(stateTTL)
+	}
+
+	h := handlers.New(bot, service, store)
+	if err := h.RegisterCommands(); err != nil {
+		log.Printf("Error registering bot commands: %v", err)
+	}
+
+	// Выбираем способ получения обновлений: long polling или вебхук
+	var updates tgbotapi.UpdatesChannel
+	var stopUpdates func(context.Context) error
+	switch mode := os.Getenv("BOT_MODE"); mode {
+	case "", "polling":
+		updates, stopUpdates, err = startPolling(bot)
+	case "webhook":
+		var cfg webhookConfig
+		cfg, err = webhookConfigFromEnv()
+		if err == nil {
+			updates, stopUpdates, err = startWebhook(bot, cfg)
+		}
+	default:
+		err = fmt.Errorf("unknown BOT_MODE %q", mode)
+	}
+	if err != nil {
+		log.Fatalf("Error starting updates: %v", err)
+	}
+
+	// Обновления обрабатываются пулом воркеров, по очереди в пределах чата
+	workers := defaultWorkers
+	if n, err := strconv.Atoi(os.Getenv("WORKER_COUNT")); err == nil && n > 0 {
+		workers = n
+	}
+	d := dispatcher.New(workers, 100, h.Route)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Обрабатываем обновления до сигнала остановки
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown(updates, stopUpdates, d, store)
+			return
+		case update, ok := <-updates:
+			if !ok {
+				shutdown(nil, stopUpdates, d, store)
+				return
+			}
+			d.Dispatch(update)
+		}
+	}
+}
+
+// shutdown останавливает получение обновлений, дожидается обработки
+// уже полученных и закрывает хранилище, сбрасывая его на диск.
+// Если обработка не успела закончиться, хранилище не закрывается
+func shutdown(updates tgbotapi.UpdatesChannel, stopUpdates func(context.Context) error, d *dispatcher.Dispatcher, store session.Store) {
+	log.Println("Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Пока получение останавливается, разбираем обновления, которые
+	// успели прийти, иначе вебхук не сможет завершить запросы
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- stopUpdates(ctx)
+	}()
+	for stopping := true; stopping; {
+		select {
+		case err := <-stopped:
+			if err != nil {
+				log.Printf("Error stopping updates: %v", err)
 			}
-		} else if update.CallbackQuery != nil {
-			go handlers.HandleCallback(bot, update)
+			stopping = false
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			d.Dispatch(update)
+		}
+	}
+
+	// Забираем то, что осталось в буфере канала
+	for drained := false; !drained && updates != nil; {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				drained = true
+				continue
+			}
+			d.Dispatch(update)
+		default:
+			drained = true
 		}
 	}
+
+	if err := d.Shutdown(ctx); err != nil {
+		// Оставшиеся воркеры еще пишут в хранилище, закрывать его нельзя.
+		// bbolt сбрасывает на диск каждую завершенную транзакцию, так что
+		// уже сохраненные состояния не потеряются
+		log.Printf("Not all updates were handled before shutdown, leaving state store open: %v", err)
+		return
+	}
+	if err := store.Close(); err != nil {
+		log.Printf("Error closing state store: %v", err)
+	}
 }
@@ -0,0 +1,439 @@
+package session
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"tg-bot/models"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// searchesBucket содержит вложенный бакет на каждый чат: SearchID -> состояние
+	// в формате encodeSearch
+	searchesBucket = []byte("searches")
+	// latestBucket chatID -> SearchID текущего поиска
+	latestBucket = []byte("latest")
+	// prefsBucket userID -> настройки пользователя
+	prefsBucket = []byte("prefs")
+	// favoritesBucket userID -> избранные достопримечательности
+	favoritesBucket = []byte("favorites")
+	// routesBucket userID -> маршрут пользователя
+	routesBucket = []byte("routes")
+)
+
+// BoltStore хранит состояния в файле базы bbolt, поэтому кнопки
+// в старых сообщениях продолжают работать после перезапуска бота.
+// Состояния, не обновлявшиеся дольше ttl, удаляются
+type BoltStore struct {
+	db    *bolt.DB
+	ttl   time.Duration
+	locks chatLocks
+	users chatLocks
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// OpenBoltStore открывает или создает файл базы по пути path.
+// ttl <= 0 отключает устаревание
+func OpenBoltStore(path string, ttl time.Duration) (*BoltStore, error) {
+	// Таймаут не дает второму экземпляру бота зависнуть на блокировке файла
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(searchesBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(latestBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(prefsBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(favoritesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(routesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &BoltStore{
+		db:   db,
+		ttl:  ttl,
+		stop: make(chan struct{}),
+	}
+	if ttl > 0 {
+		go s.janitor(ttl / 2)
+	}
+	return s, nil
+}
+
+func (s *BoltStore) Get(chatID int64) (*PaginationState, bool) {
+	var state *PaginationState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		searchID := tx.Bucket(latestBucket).Get(chatKey(chatID))
+		if searchID == nil {
+			return nil
+		}
+		var err error
+		state, err = readSearch(tx, chatID, searchID)
+		return err
+	})
+	return s.result(chatID, state, err)
+}
+
+func (s *BoltStore) Search(chatID int64, searchID string) (*PaginationState, bool) {
+	var state *PaginationState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		state, err = readSearch(tx, chatID, []byte(searchID))
+		return err
+	})
+	return s.result(chatID, state, err)
+}
+
+func (s *BoltStore) result(chatID int64, state *PaginationState, err error) (*PaginationState, bool) {
+	if err != nil {
+		log.Printf("Ошибка чтения состояния чата %d: %v", chatID, err)
+		return nil, false
+	}
+	if state == nil || s.expired(state.UpdatedAt) {
+		return nil, false
+	}
+	return state, true
+}
+
+func (s *BoltStore) Put(chatID int64, state *PaginationState) error {
+	state = state.Clone()
+	state.UpdatedAt = time.Now()
+
+	data, err := encodeSearch(state)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		chat, err := tx.Bucket(searchesBucket).CreateBucketIfNotExists(chatKey(chatID))
+		if err != nil {
+			return err
+		}
+		if err := chat.Put([]byte(state.SearchID), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(latestBucket).Put(chatKey(chatID), []byte(state.SearchID)); err != nil {
+			return err
+		}
+		return pruneSearches(chat, func(time.Time) bool { return false })
+	})
+}
+
+func (s *BoltStore) Delete(chatID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(searchesBucket).DeleteBucket(chatKey(chatID))
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		return tx.Bucket(latestBucket).Delete(chatKey(chatID))
+	})
+}
+
+func (s *BoltStore) Preferences(userID int64) Preferences {
+	prefs := DefaultPreferences()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(prefsBucket).Get(chatKey(userID))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &prefs)
+	})
+	if err != nil {
+		log.Printf("Ошибка чтения настроек пользователя %d: %v", userID, err)
+		return DefaultPreferences()
+	}
+	return prefs
+}
+
+func (s *BoltStore) SavePreferences(userID int64, prefs Preferences) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(prefsBucket).Put(chatKey(userID), data)
+	})
+}
+
+func (s *BoltStore) Favorites(userID int64) []models.Attraction {
+	var favorites []models.Attraction
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		favorites, err = readFavorites(tx, userID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Ошибка чтения избранного пользователя %d: %v", userID, err)
+		return nil
+	}
+	return favorites
+}
+
+func (s *BoltStore) AddFavorite(userID int64, attraction models.Attraction) error {
+	return s.updateFavorites(userID, func(favorites []models.Attraction) ([]models.Attraction, error) {
+		return addFavorite(favorites, attraction)
+	})
+}
+
+func (s *BoltStore) RemoveFavorite(userID int64, attractionID int) error {
+	return s.updateFavorites(userID, func(favorites []models.Attraction) ([]models.Attraction, error) {
+		return removeFavorite(favorites, attractionID), nil
+	})
+}
+
+// updateFavorites читает и перезаписывает избранное пользователя в одной транзакции
+func (s *BoltStore) updateFavorites(userID int64, update func([]models.Attraction) ([]models.Attraction, error)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		favorites, err := readFavorites(tx, userID)
+		if err != nil {
+			return err
+		}
+		favorites, err = update(favorites)
+		if err != nil {
+			return err
+		}
+
+		bucket := tx.Bucket(favoritesBucket)
+		if len(favorites) == 0 {
+			return bucket.Delete(chatKey(userID))
+		}
+		data, err := json.Marshal(favorites)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(chatKey(userID), data)
+	})
+}
+
+func (s *BoltStore) Route(userID int64) Route {
+	var route Route
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(routesBucket).Get(chatKey(userID))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &route)
+	})
+	if err != nil {
+		log.Printf("Ошибка чтения маршрута пользователя %d: %v", userID, err)
+		return Route{}
+	}
+	return route
+}
+
+func (s *BoltStore) SaveRoute(userID int64, route Route) error {
+	if route.Start == nil && len(route.Stops) == 0 {
+		return s.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(routesBucket).Delete(chatKey(userID))
+		})
+	}
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(routesBucket).Put(chatKey(userID), data)
+	})
+}
+
+func (s *BoltStore) Lock(chatID int64) func() {
+	return s.locks.lock(chatID)
+}
+
+func (s *BoltStore) LockUser(userID int64) func() {
+	return s.users.lock(userID)
+}
+
+// Close останавливает очистку и закрывает базу, сбрасывая данные на диск
+func (s *BoltStore) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.stop)
+		err = s.db.Close()
+	})
+	return err
+}
+
+func (s *BoltStore) expired(updatedAt time.Time) bool {
+	return s.ttl > 0 && time.Since(updatedAt) > s.ttl
+}
+
+// janitor удаляет устаревшие состояния, пока хранилище не закрыто
+func (s *BoltStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sweep(); err != nil {
+				log.Printf("Ошибка очистки состояний: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *BoltStore) sweep() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		searches := tx.Bucket(searchesBucket)
+
+		// Изменять бакеты во время обхода нельзя, поэтому сначала собираем ключи
+		var chats [][]byte
+		err := searches.ForEach(func(k, _ []byte) error {
+			chats = append(chats, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range chats {
+			chat := searches.Bucket(k)
+			if chat == nil {
+				continue
+			}
+			if err := pruneSearches(chat, s.expired); err != nil {
+				return err
+			}
+			if first, _ := chat.Cursor().First(); first != nil {
+				continue
+			}
+			if err := searches.DeleteBucket(k); err != nil {
+				return err
+			}
+			if err := tx.Bucket(latestBucket).Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readSearch читает поиск чата. Отсутствующий поиск возвращается как nil
+func readSearch(tx *bolt.Tx, chatID int64, searchID []byte) (*PaginationState, error) {
+	chat := tx.Bucket(searchesBucket).Bucket(chatKey(chatID))
+	if chat == nil {
+		return nil, nil
+	}
+	data := chat.Get(searchID)
+	if data == nil {
+		return nil, nil
+	}
+	return decodeSearch(data)
+}
+
+// Длина метки времени в начале записи поиска
+const updatedAtSize = 8
+
+// encodeSearch кодирует поиск как время обновления в наносекундах и JSON.
+// Время лежит отдельно, чтобы очистка не разбирала списки достопримечательностей
+func encodeSearch(state *PaginationState) ([]byte, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, updatedAtSize, updatedAtSize+len(data))
+	binary.BigEndian.PutUint64(buf, uint64(state.UpdatedAt.UnixNano()))
+	return append(buf, data...), nil
+}
+
+func decodeSearch(data []byte) (*PaginationState, error) {
+	if len(data) < updatedAtSize {
+		return nil, errors.New("session: search record is too short")
+	}
+	state := &PaginationState{}
+	if err := json.Unmarshal(data[updatedAtSize:], state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// searchUpdatedAt время обновления из записи поиска без разбора JSON
+func searchUpdatedAt(data []byte) (time.Time, bool) {
+	if len(data) < updatedAtSize {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))), true
+}
+
+// readFavorites читает избранное пользователя
+func readFavorites(tx *bolt.Tx, userID int64) ([]models.Attraction, error) {
+	data := tx.Bucket(favoritesBucket).Get(chatKey(userID))
+	if data == nil {
+		return nil, nil
+	}
+	var favorites []models.Attraction
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// pruneSearches удаляет из бакета чата поиски, для времени обновления которых
+// stale вернула true, и самые старые поиски сверх MaxSearchesPerChat
+func pruneSearches(chat *bolt.Bucket, stale func(updatedAt time.Time) bool) error {
+	type entry struct {
+		key       []byte
+		updatedAt time.Time
+	}
+
+	var keep []entry
+	var remove [][]byte
+	err := chat.ForEach(func(k, v []byte) error {
+		key := append([]byte(nil), k...)
+		updatedAt, ok := searchUpdatedAt(v)
+		if !ok || stale(updatedAt) {
+			remove = append(remove, key)
+			return nil
+		}
+		keep = append(keep, entry{key: key, updatedAt: updatedAt})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(keep) > MaxSearchesPerChat {
+		sort.Slice(keep, func(i, j int) bool {
+			return keep[i].updatedAt.Before(keep[j].updatedAt)
+		})
+		for _, e := range keep[:len(keep)-MaxSearchesPerChat] {
+			remove = append(remove, e.key)
+		}
+	}
+
+	for _, k := range remove {
+		if err := chat.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chatKey кодирует chatID или userID в ключ базы
+func chatKey(chatID int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(chatID))
+	return key
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"tg-bot/models"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestBoltStore(t *testing.T, path string, ttl time.Duration) *BoltStore {
+	t.Helper()
+	s, err := OpenBoltStore(path, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestBoltStore(t *testing.T) {
+	testStoreSearches(t, func(t *testing.T, ttl time.Duration) Store {
+		return openTestBoltStore(t, filepath.Join(t.TempDir(), "state.db"), ttl)
+	})
+}
+
+func TestBoltStoreReopen(t *testing.T) {
+	const chatID, userID = 42, 7
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	s := openTestBoltStore(t, path, time.Hour)
+	s.Put(chatID, newState("old"))
+	current := newState("current")
+	current.Page = 2
+	s.Put(chatID, current)
+	s.SavePreferences(userID, Preferences{Radius: 3000, AlertDistance: 100})
+	s.AddFavorite(userID, models.Attraction{ID: 5, Name: "Кремль"})
+	s.SaveRoute(userID, Route{
+		Start: &tgbotapi.Location{Latitude: 57.6, Longitude: 39.8},
+		Stops: []models.Attraction{{ID: 5}},
+	})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openTestBoltStore(t, path, time.Hour)
+	got, ok := s.Get(chatID)
+	if !ok || got.SearchID != "current" || got.Page != 2 || len(got.Attractions) != 1 {
+		t.Errorf("Get() after reopen = %+v, %v", got, ok)
+	}
+	if _, ok := s.Search(chatID, "old"); !ok {
+		t.Error("Search(old) lost after reopen")
+	}
+	if prefs := s.Preferences(userID); prefs.Radius != 3000 || prefs.AlertDistance != 100 {
+		t.Errorf("Preferences() after reopen = %+v", prefs)
+	}
+	if favorites := s.Favorites(userID); len(favorites) != 1 || favorites[0].ID != 5 {
+		t.Errorf("Favorites() after reopen = %+v", favorites)
+	}
+	if route := s.Route(userID); route.Start == nil || len(route.Stops) != 1 {
+		t.Errorf("Route() after reopen = %+v", route)
+	}
+}
+
+func TestBoltStoreSweep(t *testing.T) {
+	// Без janitor, чтобы очистку запускал только тест
+	s := openTestBoltStore(t, filepath.Join(t.TempDir(), "state.db"), 0)
+	s.Put(1, newState("old"))
+	time.Sleep(100 * time.Millisecond)
+	s.Put(2, newState("fresh"))
+
+	s.ttl = 50 * time.Millisecond
+	if err := s.sweep(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(searchesBucket).Bucket(chatKey(1)) != nil {
+			t.Error("sweep() kept chat without live searches")
+		}
+		if tx.Bucket(latestBucket).Get(chatKey(1)) != nil {
+			t.Error("sweep() kept current search of removed chat")
+		}
+		return nil
+	})
+	if _, ok := s.Search(2, "fresh"); !ok {
+		t.Error("sweep() removed live search")
+	}
+}
+
+func TestSearchRecord(t *testing.T) {
+	state := newState("a")
+	state.UpdatedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := encodeSearch(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedAt, ok := searchUpdatedAt(data); !ok || !updatedAt.Equal(state.UpdatedAt) {
+		t.Errorf("searchUpdatedAt() = %v, %v, want %v", updatedAt, ok, state.UpdatedAt)
+	}
+	got, err := decodeSearch(data)
+	if err != nil || got.SearchID != "a" || !got.UpdatedAt.Equal(state.UpdatedAt) {
+		t.Errorf("decodeSearch() = %+v, %v", got, err)
+	}
+
+	if _, ok := searchUpdatedAt([]byte("{}")); ok {
+		t.Error("searchUpdatedAt() accepted short record")
+	}
+	if _, err := decodeSearch([]byte("{}")); err == nil {
+		t.Error("decodeSearch() accepted short record")
+	}
+}
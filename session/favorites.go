@@ -0,0 +1,45 @@
+package session
+
+import (
+	"errors"
+	"tg-bot/models"
+)
+
+// MaxFavorites сколько достопримечательностей можно добавить в избранное
+const MaxFavorites = 100
+
+// ErrFavoritesFull возвращается, когда в избранном уже MaxFavorites записей
+var ErrFavoritesFull = errors.New("session: favorites list is full")
+
+// IsFavorite проверяет, есть ли достопримечательность в списке избранного
+func IsFavorite(favorites []models.Attraction, attractionID int) bool {
+	for _, attr := range favorites {
+		if attr.ID == attractionID {
+			return true
+		}
+	}
+	return false
+}
+
+// addFavorite добавляет достопримечательность в конец списка.
+// Если она уже есть, список возвращается без изменений
+func addFavorite(favorites []models.Attraction, attraction models.Attraction) ([]models.Attraction, error) {
+	if IsFavorite(favorites, attraction.ID) {
+		return favorites, nil
+	}
+	if len(favorites) >= MaxFavorites {
+		return favorites, ErrFavoritesFull
+	}
+	return append(favorites, attraction), nil
+}
+
+// removeFavorite возвращает список без достопримечательности attractionID
+func removeFavorite(favorites []models.Attraction, attractionID int) []models.Attraction {
+	kept := make([]models.Attraction, 0, len(favorites))
+	for _, attr := range favorites {
+		if attr.ID != attractionID {
+			kept = append(kept, attr)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,45 @@
+package session
+
+import "sync"
+
+// chatLocks набор блокировок по chatID. Блокировка удаляется,
+// когда ее никто не держит и не ждет, поэтому набор не растет бесконечно
+type chatLocks struct {
+	mu    sync.Mutex
+	locks map[int64]*chatLock
+}
+
+type chatLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func (l *chatLocks) lock(chatID int64) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[int64]*chatLock)
+	}
+	cl, ok := l.locks[chatID]
+	if !ok {
+		cl = &chatLock{}
+		l.locks[chatID] = cl
+	}
+	cl.refs++
+	l.mu.Unlock()
+
+	cl.mu.Lock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cl.mu.Unlock()
+
+			l.mu.Lock()
+			cl.refs--
+			if cl.refs == 0 {
+				delete(l.locks, chatID)
+			}
+			l.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package session
+
+import (
+	"sync"
+	"tg-bot/models"
+	"time"
+)
+
+// MemoryStore хранит состояния в памяти процесса.
+// Состояния, не обновлявшиеся дольше ttl, удаляются
+type MemoryStore struct {
+	mu     sync.RWMutex
+	ttl    time.Duration
+	chats  map[int64]*chatSearches
+	prefs  map[int64]Preferences
+	favs   map[int64][]models.Attraction
+	routes map[int64]Route
+	locks  chatLocks
+	users  chatLocks
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// chatSearches последние поиски чата, от старых к новым
+type chatSearches struct {
+	searches []*PaginationState
+}
+
+// NewMemoryStore создает хранилище и запускает периодическую очистку
+// устаревших состояний. ttl <= 0 отключает устаревание
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		ttl:    ttl,
+		chats:  make(map[int64]*chatSearches),
+		prefs:  make(map[int64]Preferences),
+		favs:   make(map[int64][]models.Attraction),
+		routes: make(map[int64]Route),
+		stop:   make(chan struct{}),
+	}
+	if ttl > 0 {
+		go s.janitor(ttl / 2)
+	}
+	return s
+}
+
+func (s *MemoryStore) Get(chatID int64) (*PaginationState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chat, ok := s.chats[chatID]
+	if !ok || len(chat.searches) == 0 {
+		return nil, false
+	}
+	state := chat.searches[len(chat.searches)-1]
+	if s.expired(state) {
+		return nil, false
+	}
+	return state.Clone(), true
+}
+
+func (s *MemoryStore) Search(chatID int64, searchID string) (*PaginationState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chat, ok := s.chats[chatID]
+	if !ok {
+		return nil, false
+	}
+	for _, state := range chat.searches {
+		if state.SearchID == searchID && !s.expired(state) {
+			return state.Clone(), true
+		}
+	}
+	return nil, false
+}
+
+func (s *MemoryStore) Put(chatID int64, state *PaginationState) error {
+	state = state.Clone()
+	state.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.chats[chatID]
+	if !ok {
+		chat = &chatSearches{}
+		s.chats[chatID] = chat
+	}
+
+	// Убираем прежнюю версию поиска и добавляем новую в конец как текущую
+	searches := chat.searches[:0]
+	for _, existing := range chat.searches {
+		if existing.SearchID != state.SearchID {
+			searches = append(searches, existing)
+		}
+	}
+	searches = append(searches, state)
+	if len(searches) > MaxSearchesPerChat {
+		searches = searches[len(searches)-MaxSearchesPerChat:]
+	}
+	chat.searches = searches
+	return nil
+}
+
+func (s *MemoryStore) Delete(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.chats, chatID)
+	return nil
+}
+
+func (s *MemoryStore) Preferences(userID int64) Preferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if prefs, ok := s.prefs[userID]; ok {
+		return prefs
+	}
+	return DefaultPreferences()
+}
+
+func (s *MemoryStore) SavePreferences(userID int64, prefs Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prefs[userID] = prefs
+	return nil
+}
+
+func (s *MemoryStore) Favorites(userID int64) []models.Attraction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.Attraction(nil), s.favs[userID]...)
+}
+
+func (s *MemoryStore) AddFavorite(userID int64, attraction models.Attraction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorites, err := addFavorite(s.favs[userID], attraction)
+	if err != nil {
+		return err
+	}
+	s.favs[userID] = favorites
+	return nil
+}
+
+func (s *MemoryStore) RemoveFavorite(userID int64, attractionID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorites := removeFavorite(s.favs[userID], attractionID)
+	if len(favorites) == 0 {
+		delete(s.favs, userID)
+		return nil
+	}
+	s.favs[userID] = favorites
+	return nil
+}
+
+func (s *MemoryStore) Route(userID int64) Route {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.routes[userID].Clone()
+}
+
+func (s *MemoryStore) SaveRoute(userID int64, route Route) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if route.Start == nil && len(route.Stops) == 0 {
+		delete(s.routes, userID)
+		return nil
+	}
+	s.routes[userID] = route.Clone()
+	return nil
+}
+
+func (s *MemoryStore) Lock(chatID int64) func() {
+	return s.locks.lock(chatID)
+}
+
+func (s *MemoryStore) LockUser(userID int64) func() {
+	return s.users.lock(userID)
+}
+
+// Close останавливает периодическую очистку
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+func (s *MemoryStore) expired(state *PaginationState) bool {
+	return s.ttl > 0 && time.Since(state.UpdatedAt) > s.ttl
+}
+
+// janitor удаляет устаревшие состояния, пока хранилище не закрыто
+func (s *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *MemoryStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, chat := range s.chats {
+		searches := chat.searches[:0]
+		for _, state := range chat.searches {
+			if !s.expired(state) {
+				searches = append(searches, state)
+			}
+		}
+		chat.searches = searches
+		if len(searches) == 0 {
+			delete(s.chats, chatID)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func openMemoryStore(t *testing.T, ttl time.Duration) Store {
+	s := NewMemoryStore(ttl)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreSearches(t, openMemoryStore)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	// Без janitor, чтобы очистку запускал только тест
+	s := NewMemoryStore(0)
+	s.Put(1, newState("old"))
+	s.Put(2, newState("fresh"))
+
+	s.ttl = time.Minute
+	s.chats[1].searches[0].UpdatedAt = time.Now().Add(-2 * time.Minute)
+	s.sweep()
+
+	if _, ok := s.chats[1]; ok {
+		t.Error("sweep() kept chat without live searches")
+	}
+	if _, ok := s.Search(2, "fresh"); !ok {
+		t.Error("sweep() removed live search")
+	}
+}
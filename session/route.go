@@ -0,0 +1,67 @@
+package session
+
+import (
+	"errors"
+	"tg-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxRouteStops сколько достопримечательностей помещается в маршрут.
+// Ссылка Google Maps принимает не больше 9 промежуточных точек и конечную
+const MaxRouteStops = 10
+
+// ErrRouteFull возвращается, когда в маршруте уже MaxRouteStops остановок
+var ErrRouteFull = errors.New("session: route is full")
+
+// Route пешеходный маршрут пользователя
+type Route struct {
+	// Start последняя геолокация пользователя, от нее начинается маршрут
+	Start *tgbotapi.Location
+	// Stops достопримечательности маршрута в порядке добавления
+	Stops []models.Attraction
+}
+
+// HasStop проверяет, есть ли достопримечательность в маршруте
+func (r Route) HasStop(attractionID int) bool {
+	for _, stop := range r.Stops {
+		if stop.ID == attractionID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddStop добавляет достопримечательность в маршрут.
+// Если она уже есть, маршрут не меняется
+func (r *Route) AddStop(attraction models.Attraction) error {
+	if r.HasStop(attraction.ID) {
+		return nil
+	}
+	if len(r.Stops) >= MaxRouteStops {
+		return ErrRouteFull
+	}
+	r.Stops = append(r.Stops, attraction)
+	return nil
+}
+
+// RemoveStop убирает достопримечательность из маршрута
+func (r *Route) RemoveStop(attractionID int) {
+	stops := make([]models.Attraction, 0, len(r.Stops))
+	for _, stop := range r.Stops {
+		if stop.ID != attractionID {
+			stops = append(stops, stop)
+		}
+	}
+	r.Stops = stops
+}
+
+// Clone возвращает копию маршрута
+func (r Route) Clone() Route {
+	clone := Route{Stops: append([]models.Attraction(nil), r.Stops...)}
+	if r.Start != nil {
+		start := *r.Start
+		clone.Start = &start
+	}
+	return clone
+}
@@ -0,0 +1,142 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"tg-bot/models"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SearchType тип поиска, по которому получен список
+type SearchType int
+
+const (
+	SearchTypeCity SearchType = iota
+	SearchTypeLocation
+	SearchTypeFavorites
+)
+
+// SortMode порядок списка результатов
+type SortMode string
+
+const (
+	// SortDefault порядок API. Поиск рядом начинается с SortDistance
+	SortDefault  SortMode = ""
+	SortRating   SortMode = "rating"
+	SortName     SortMode = "name"
+	SortDistance SortMode = "distance"
+)
+
+// MaxSearchesPerChat сколько последних поисков чата хранится, чтобы
+// кнопки в старых сообщениях продолжали работать
+const MaxSearchesPerChat = 5
+
+// PaginationState контекст поиска чата и текущая страница результатов
+type PaginationState struct {
+	// SearchID идентификатор поиска, который передается в кнопках
+	SearchID    string
+	Type        SearchType
+	City        string
+	Location    *tgbotapi.Location
+	Attractions []models.Attraction
+	Page        int
+	TotalPages  int
+	// Sort, MinRating и WithPhoto выбранные пользователем сортировка и фильтры.
+	// Attractions хранится в исходном порядке, список для показа строится из него
+	Sort      SortMode
+	MinRating float64
+	WithPhoto bool
+	// Query слова поиска по результатам, список показывается по релевантности.
+	// AwaitingQuery означает, что бот попросил ввести запрос: им считается
+	// ответ на сообщение QueryPrompt или сообщение пользователя QueryFrom,
+	// который нажал кнопку поиска
+	Query         string
+	AwaitingQuery bool
+	QueryFrom     int64
+	QueryPrompt   int
+	UpdatedAt     time.Time
+}
+
+// Clone возвращает копию состояния. Список Attractions после сохранения
+// не изменяется, поэтому копируется только ссылка на него
+func (s *PaginationState) Clone() *PaginationState {
+	clone := *s
+	if s.Location != nil {
+		location := *s.Location
+		clone.Location = &location
+	}
+	return &clone
+}
+
+// NewSearchID создает короткий случайный идентификатор поиска
+func NewSearchID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand не должен отказывать; на всякий случай берем время
+		binary.BigEndian.PutUint32(b, uint32(time.Now().UnixNano()))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DefaultRadius радиус поиска рядом по умолчанию, в метрах
+const DefaultRadius = 1000
+
+// DefaultAlertDistance на каком расстоянии до достопримечательности
+// по умолчанию приходит уведомление во время трансляции геопозиции, в метрах
+const DefaultAlertDistance = 200
+
+// Preferences настройки пользователя
+type Preferences struct {
+	// Radius радиус поиска рядом, в метрах
+	Radius int
+	// AlertDistance расстояние для уведомлений о местах рядом, в метрах
+	AlertDistance int
+}
+
+// DefaultPreferences настройки пользователя, который ничего не менял
+func DefaultPreferences() Preferences {
+	return Preferences{Radius: DefaultRadius, AlertDistance: DefaultAlertDistance}
+}
+
+// Store хранилище состояний пагинации по chatID, настроек, избранного
+// и маршрутов по userID.
+// Для каждого чата хранятся MaxSearchesPerChat последних поисков,
+// последний сохраненный считается текущим.
+// Get, Search и Put работают с копиями, поэтому изменения состояния нужно
+// сохранять через Put. Для цепочки Get-изменение-Put чат блокируется через Lock
+type Store interface {
+	// Get возвращает текущий поиск чата, если он есть и не устарел
+	Get(chatID int64) (*PaginationState, bool)
+	// Search возвращает поиск чата по его SearchID
+	Search(chatID int64, searchID string) (*PaginationState, bool)
+	// Put сохраняет поиск, делает его текущим и продлевает срок жизни
+	Put(chatID int64, state *PaginationState) error
+	// Delete удаляет все поиски чата
+	Delete(chatID int64) error
+	// Preferences возвращает настройки пользователя или настройки по умолчанию
+	Preferences(userID int64) Preferences
+	// SavePreferences сохраняет настройки пользователя. Настройки не устаревают
+	SavePreferences(userID int64, prefs Preferences) error
+	// Favorites возвращает избранное пользователя в порядке добавления
+	Favorites(userID int64) []models.Attraction
+	// AddFavorite добавляет достопримечательность в избранное.
+	// Если избранное заполнено, возвращает ErrFavoritesFull
+	AddFavorite(userID int64, attraction models.Attraction) error
+	// RemoveFavorite убирает достопримечательность из избранного
+	RemoveFavorite(userID int64, attractionID int) error
+	// Route возвращает маршрут пользователя, пустой, если его нет
+	Route(userID int64) Route
+	// SaveRoute сохраняет маршрут пользователя. Пустой маршрут удаляется
+	SaveRoute(userID int64, route Route) error
+	// Lock захватывает блокировку чата и возвращает функцию для ее снятия
+	Lock(chatID int64) (unlock func())
+	// LockUser захватывает блокировку пользователя для цепочки
+	// Route-изменение-SaveRoute. Она не зависит от Lock, даже если userID
+	// совпадает с chatID, поэтому их можно держать одновременно
+	LockUser(userID int64) (unlock func())
+	// Close освобождает ресурсы хранилища
+	Close() error
+}
@@ -0,0 +1,147 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"tg-bot/models"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// openStore создает пустое хранилище для теста
+type openStore func(t *testing.T, ttl time.Duration) Store
+
+// newState поиск с одной достопримечательностью
+func newState(searchID string) *PaginationState {
+	return &PaginationState{
+		SearchID:    searchID,
+		Type:        SearchTypeLocation,
+		Location:    &tgbotapi.Location{Latitude: 57.6, Longitude: 39.8},
+		Attractions: []models.Attraction{{ID: 1, Name: "Кремль"}},
+		TotalPages:  1,
+	}
+}
+
+// testStoreSearches проверяет общие для всех хранилищ правила работы с поисками
+func testStoreSearches(t *testing.T, open openStore) {
+	const chatID = 42
+
+	t.Run("put and get", func(t *testing.T) {
+		s := open(t, time.Hour)
+		if _, ok := s.Get(chatID); ok {
+			t.Fatal("Get() on empty store found a search")
+		}
+		if err := s.Put(chatID, newState("a")); err != nil {
+			t.Fatal(err)
+		}
+
+		got, ok := s.Get(chatID)
+		if !ok || got.SearchID != "a" || len(got.Attractions) != 1 {
+			t.Fatalf("Get() = %+v, %v", got, ok)
+		}
+		if got.UpdatedAt.IsZero() {
+			t.Error("Put() did not set UpdatedAt")
+		}
+		if _, ok := s.Search(chatID, "b"); ok {
+			t.Error("Search() found unknown search")
+		}
+		if _, ok := s.Get(chatID + 1); ok {
+			t.Error("Get() found a search of another chat")
+		}
+	})
+
+	t.Run("put replaces search with the same id", func(t *testing.T) {
+		s := open(t, time.Hour)
+		s.Put(chatID, newState("a"))
+		s.Put(chatID, newState("b"))
+
+		updated := newState("a")
+		updated.Page = 3
+		s.Put(chatID, updated)
+
+		got, ok := s.Get(chatID)
+		if !ok || got.SearchID != "a" || got.Page != 3 {
+			t.Fatalf("Get() = %+v, %v, want updated search a", got, ok)
+		}
+		if _, ok := s.Search(chatID, "b"); !ok {
+			t.Error("Search(b) lost after replacing a")
+		}
+	})
+
+	t.Run("keeps last searches", func(t *testing.T) {
+		s := open(t, time.Hour)
+		for i := 0; i <= MaxSearchesPerChat; i++ {
+			s.Put(chatID, newState(fmt.Sprint(i)))
+		}
+
+		if _, ok := s.Search(chatID, "0"); ok {
+			t.Error("oldest search was not evicted")
+		}
+		for i := 1; i <= MaxSearchesPerChat; i++ {
+			if _, ok := s.Search(chatID, fmt.Sprint(i)); !ok {
+				t.Errorf("Search(%d) evicted", i)
+			}
+		}
+	})
+
+	t.Run("expires after ttl", func(t *testing.T) {
+		s := open(t, 50*time.Millisecond)
+		s.Put(chatID, newState("a"))
+		time.Sleep(100 * time.Millisecond)
+
+		if _, ok := s.Get(chatID); ok {
+			t.Error("Get() returned expired search")
+		}
+		if _, ok := s.Search(chatID, "a"); ok {
+			t.Error("Search() returned expired search")
+		}
+	})
+
+	t.Run("clone isolation", func(t *testing.T) {
+		s := open(t, time.Hour)
+		state := newState("a")
+		s.Put(chatID, state)
+
+		// Изменения исходного и прочитанного состояния не попадают в хранилище
+		state.Page = 5
+		state.Location.Latitude = 0
+		got, _ := s.Get(chatID)
+		got.Page = 7
+		got.Location.Longitude = 0
+
+		got, _ = s.Get(chatID)
+		if got.Page != 0 || got.Location.Latitude != 57.6 || got.Location.Longitude != 39.8 {
+			t.Errorf("stored state changed through a copy: %+v, %+v", got, got.Location)
+		}
+	})
+
+	t.Run("user lock is separate from chat lock", func(t *testing.T) {
+		s := open(t, time.Hour)
+		done := make(chan struct{})
+		go func() {
+			// В личном чате userID равен chatID
+			unlockChat := s.Lock(chatID)
+			unlockUser := s.LockUser(chatID)
+			unlockUser()
+			unlockChat()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("LockUser() waits for Lock() with the same id")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := open(t, time.Hour)
+		s.Put(chatID, newState("a"))
+		if err := s.Delete(chatID); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := s.Search(chatID, "a"); ok {
+			t.Error("Search() found deleted search")
+		}
+	})
+}
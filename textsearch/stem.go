@@ -0,0 +1,171 @@
+package textsearch
+
+// Окончания для стеммера Snowball для русского языка.
+// Группа 1 окончаний засчитывается, только если перед ней стоит «а» или «я»
+var (
+	perfectiveGerund1 = []string{"вшись", "вши", "в"}
+	perfectiveGerund2 = []string{"ывшись", "ившись", "ывши", "ивши", "ыв", "ив"}
+
+	adjective = []string{
+		"ими", "ыми", "его", "ого", "ему", "ому",
+		"ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+
+	participle1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2 = []string{"ивш", "ывш", "ующ"}
+
+	reflexive = []string{"ся", "сь"}
+
+	verb1 = []string{"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть", "й", "л", "н"}
+	verb2 = []string{
+		"ейте", "уйте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено", "ует", "уют",
+		"ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ят", "ит", "ыт", "ую", "ю",
+	}
+
+	noun = []string{
+		"иями", "ями", "ами", "ией", "иям", "ием", "иях",
+		"ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я",
+	}
+
+	superlative  = []string{"ейше", "ейш"}
+	derivational = []string{"ость", "ост"}
+)
+
+func isVowel(r rune) bool {
+	switch r {
+	case 'а', 'е', 'и', 'о', 'у', 'ы', 'э', 'ю', 'я':
+		return true
+	}
+	return false
+}
+
+// Stem возвращает основу русского слова в нижнем регистре по алгоритму
+// Snowball, так что «музей» и «музеи» дают одну основу «муз». Основы разных
+// форм не всегда равны: «музеев» дает «музе», поэтому Query сравнивает
+// основы еще и по началу
+func Stem(word string) string {
+	w := []rune(word)
+	for i, r := range w {
+		if r == 'ё' {
+			w[i] = 'е'
+		}
+	}
+
+	// RV — часть слова после первой гласной, R2 — после второго
+	// сочетания «гласная, согласная»
+	rv := len(w)
+	for i, r := range w {
+		if isVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	r2 := region(w, region(w, 0))
+
+	// Шаг 1
+	var ok bool
+	if w, ok = removeGrouped(w, rv, perfectiveGerund1, perfectiveGerund2); !ok {
+		w, _ = removeSuffix(w, rv, reflexive)
+		if w, ok = removeAdjectival(w, rv); !ok {
+			if w, ok = removeGrouped(w, rv, verb1, verb2); !ok {
+				w, _ = removeSuffix(w, rv, noun)
+			}
+		}
+	}
+
+	// Шаг 2
+	w, _ = removeSuffix(w, rv, []string{"и"})
+
+	// Шаг 3
+	w, _ = removeSuffix(w, max(rv, r2), derivational)
+
+	// Шаг 4
+	if w, ok = removeSuffix(w, rv, superlative); ok || hasSuffix(w, rv, "нн") {
+		if hasSuffix(w, rv, "нн") {
+			w = w[:len(w)-1]
+		}
+	} else {
+		w, _ = removeSuffix(w, rv, []string{"ь"})
+	}
+
+	return string(w)
+}
+
+// region начало области R1 относительно from: позиция после первой
+// согласной, которая следует за гласной
+func region(w []rune, from int) int {
+	for i := from + 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// hasSuffix проверяет, что слово оканчивается на suffix внутри области с позиции start
+func hasSuffix(w []rune, start int, suffix string) bool {
+	s := []rune(suffix)
+	if len(w)-len(s) < start {
+		return false
+	}
+	for i, r := range s {
+		if w[len(w)-len(s)+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// removeSuffix удаляет первое подходящее окончание из списка.
+// Списки упорядочены так, что длинные окончания проверяются раньше
+func removeSuffix(w []rune, start int, suffixes []string) ([]rune, bool) {
+	for _, suffix := range suffixes {
+		if hasSuffix(w, start, suffix) {
+			return w[:len(w)-len([]rune(suffix))], true
+		}
+	}
+	return w, false
+}
+
+// removeGrouped удаляет самое длинное окончание из групп: окончание группы 1
+// только после «а» или «я», которые остаются в слове
+func removeGrouped(w []rune, start int, group1, group2 []string) ([]rune, bool) {
+	best := 0
+	for _, suffix := range group2 {
+		if n := len([]rune(suffix)); n > best && hasSuffix(w, start, suffix) {
+			best = n
+		}
+	}
+	for _, suffix := range group1 {
+		n := len([]rune(suffix))
+		if n > best && hasSuffix(w, start+1, suffix) {
+			if r := w[len(w)-n-1]; r == 'а' || r == 'я' {
+				best = n
+			}
+		}
+	}
+	if best == 0 {
+		return w, false
+	}
+	return w[:len(w)-best], true
+}
+
+// removeAdjectival удаляет окончание прилагательного и стоящий перед ним
+// суффикс причастия
+func removeAdjectival(w []rune, start int) ([]rune, bool) {
+	w, ok := removeSuffix(w, start, adjective)
+	if !ok {
+		return w, false
+	}
+	w, _ = removeGrouped(w, start, participle1, participle2)
+	return w, true
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,109 @@
+// Package textsearch ищет по русскому тексту с учетом словоформ:
+// слова запроса и текста сравниваются по основам
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Слова короче не участвуют в поиске: это предлоги и союзы
+const minWordLength = 2
+
+// stopWords служебные слова из двух и более букв, которые есть почти
+// в любом тексте и не говорят о том, что ищет пользователь
+var stopWords = map[string]bool{
+	"на": true, "по": true, "из": true, "от": true, "за": true, "до": true,
+	"во": true, "со": true, "ко": true, "об": true, "обо": true, "под": true,
+	"над": true, "при": true, "про": true, "для": true, "без": true, "через": true,
+	"около": true, "возле": true, "между": true, "перед": true,
+	"не": true, "ни": true, "же": true, "ли": true, "бы": true, "да": true,
+	"но": true, "или": true, "либо": true, "что": true, "как": true, "где": true,
+	"это": true, "the": true, "of": true, "and": true,
+}
+
+// Основа слова запроса, которая совпала только с началом основы слова
+// из текста или наоборот, засчитывается с этим коэффициентом
+const prefixMatchWeight = 0.5
+
+// Основы короче не сравниваются по началу: слишком много случайных совпадений
+const minPrefixLength = 3
+
+// Terms разбивает текст на слова и возвращает их основы без служебных слов
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < minWordLength || stopWords[word] {
+			continue
+		}
+		terms = append(terms, Stem(word))
+	}
+	return terms
+}
+
+// Field текст поля документа и его вес при оценке
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Query поисковый запрос
+type Query struct {
+	terms []string
+}
+
+// NewQuery разбирает запрос на основы слов
+func NewQuery(text string) Query {
+	return Query{terms: Terms(text)}
+}
+
+// Empty сообщает, что в запросе нет слов для поиска
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+// Score оценивает, насколько документ из полей fields подходит под запрос.
+// Каждое слово запроса приносит вес лучшего поля, в котором оно нашлось.
+// 0 означает, что не нашлось ни одного слова
+func (q Query) Score(fields ...Field) float64 {
+	fieldTerms := make([][]string, len(fields))
+	for i, field := range fields {
+		fieldTerms[i] = Terms(field.Text)
+	}
+
+	score := 0.0
+	for _, term := range q.terms {
+		best := 0.0
+		for i, field := range fields {
+			if w := field.Weight * match(term, fieldTerms[i]); w > best {
+				best = w
+			}
+		}
+		score += best
+	}
+	return score
+}
+
+// match насколько основа term совпадает с одной из основ terms.
+// Совпадение по началу в обе стороны нужно, потому что основы одного
+// слова бывают разной длины: «музеев» -> «музе», «музей» -> «муз»
+func match(term string, terms []string) float64 {
+	best := 0.0
+	for _, t := range terms {
+		switch {
+		case t == term:
+			return 1
+		case isPrefix(term, t) || isPrefix(t, term):
+			best = prefixMatchWeight
+		}
+	}
+	return best
+}
+
+// isPrefix сообщает, что основа prefix достаточно длинная и начинает основу s
+func isPrefix(prefix, s string) bool {
+	return len([]rune(prefix)) >= minPrefixLength && strings.HasPrefix(s, prefix)
+}
@@ -0,0 +1,99 @@
+package textsearch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"музей", "муз"},
+		{"музеи", "муз"},
+		{"музеев", "музе"},
+		{"храм", "храм"},
+		{"храмов", "храм"},
+		{"набережная", "набережн"},
+		{"набережной", "набережн"},
+		{"монастыря", "монастыр"},
+		{"кремле", "кремл"},
+		{"ёлка", "елк"},
+		{"в", "в"},
+	}
+
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("Церковь Ильи Пророка, XVII в.")
+	want := []string{Stem("церковь"), Stem("ильи"), Stem("пророка"), "xvii"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+}
+
+func TestQueryScore(t *testing.T) {
+	museum := []Field{
+		{Text: "Музей истории города", Weight: 3},
+		{Text: "Экспозиция о набережной и храмах", Weight: 1},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  float64
+	}{
+		{name: "exact stem in name", query: "музеи", want: 3},
+		{name: "longer stem in query", query: "музеев", want: 1.5},
+		{name: "shorter stem in query", query: "экспоз", want: 0.5},
+		{name: "description only", query: "храмы", want: 1},
+		{name: "several words", query: "музей на набережной", want: 4},
+		{name: "no match", query: "парк", want: 0},
+		{name: "short prefix ignored", query: "му", want: 0},
+		{name: "empty", query: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewQuery(tt.query).Score(museum...); got != tt.want {
+				t.Errorf("Score(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryScoreIgnoresStopWords(t *testing.T) {
+	church := []Field{{Text: "Церковь на Горе", Weight: 3}}
+
+	tests := []struct {
+		query string
+		want  float64
+	}{
+		{"музей на набережной", 0},
+		{"на", 0},
+		{"церковь на горе", 6},
+	}
+
+	for _, tt := range tests {
+		if got := NewQuery(tt.query).Score(church...); got != tt.want {
+			t.Errorf("Score(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryEmpty(t *testing.T) {
+	for _, text := range []string{"", "  ", "и в", "на по для", "!!!"} {
+		if !NewQuery(text).Empty() {
+			t.Errorf("NewQuery(%q).Empty() = false", text)
+		}
+	}
+	if NewQuery("музей").Empty() {
+		t.Error(`NewQuery("музей").Empty() = true`)
+	}
+}
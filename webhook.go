@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Заголовок, в котором Telegram передает секрет вебхука
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhookConfig настройки режима вебхука из переменных окружения
+type webhookConfig struct {
+	// URL публичный адрес вебхука, который регистрируется в Telegram
+	URL string
+	// Listen адрес, на котором слушает HTTP-сервер
+	Listen string
+	// Secret значение заголовка X-Telegram-Bot-Api-Secret-Token.
+	// Если WEBHOOK_SECRET не задан, секрет генерируется при запуске
+	Secret string
+	// CertFile и KeyFile включают HTTPS. Без них сервер работает по HTTP
+	// за обратным прокси
+	CertFile string
+	KeyFile  string
+}
+
+func webhookConfigFromEnv() (webhookConfig, error) {
+	cfg := webhookConfig{
+		URL:      os.Getenv("WEBHOOK_URL"),
+		Listen:   os.Getenv("WEBHOOK_LISTEN"),
+		Secret:   os.Getenv("WEBHOOK_SECRET"),
+		CertFile: os.Getenv("WEBHOOK_CERT"),
+		KeyFile:  os.Getenv("WEBHOOK_KEY"),
+	}
+	if cfg.URL == "" {
+		return cfg, errors.New("WEBHOOK_URL is required in webhook mode")
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = ":8443"
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return cfg, errors.New("WEBHOOK_CERT and WEBHOOK_KEY must be set together")
+	}
+	if cfg.Secret == "" {
+		// Без секрета обновления мог бы прислать любой, кто знает адрес.
+		// Вебхук регистрируется заново при каждом запуске, поэтому
+		// случайного секрета достаточно
+		secret, err := randomSecret()
+		if err != nil {
+			return cfg, fmt.Errorf("generate webhook secret: %w", err)
+		}
+		cfg.Secret = secret
+		log.Println("WEBHOOK_SECRET is not set, using a random secret")
+	}
+	return cfg, nil
+}
+
+// randomSecret возвращает случайный секрет из символов, допустимых
+// в secret_token
+func randomSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// startPolling запускает получение обновлений через long polling
+func startPolling(bot *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, func(context.Context) error, error) {
+	// getUpdates не работает, пока зарегистрирован вебхук
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return nil, nil, fmt.Errorf("delete webhook: %w", err)
+	}
+
+	// Настраиваем канал обновлений
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	stop := func(context.Context) error {
+		bot.StopReceivingUpdates()
+		return nil
+	}
+	return bot.GetUpdatesChan(u), stop, nil
+}
+
+// startWebhook регистрирует вебхук в Telegram и запускает HTTP-сервер,
+// который принимает обновления. stop останавливает сервер и снимает вебхук
+func startWebhook(bot *tgbotapi.BotAPI, cfg webhookConfig) (tgbotapi.UpdatesChannel, func(context.Context) error, error) {
+	wh, err := tgbotapi.NewWebhook(cfg.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// WebhookConfig в библиотеке не поддерживает secret_token,
+	// поэтому параметры setWebhook собираем сами
+	params := tgbotapi.Params{
+		"url":          wh.URL.String(),
+		"secret_token": cfg.Secret,
+	}
+	if _, err := bot.MakeRequest("setWebhook", params); err != nil {
+		return nil, nil, fmt.Errorf("set webhook: %w", err)
+	}
+
+	path := wh.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	updates := bot.ListenForWebhook(path)
+
+	server := &http.Server{
+		Addr:    cfg.Listen,
+		Handler: requireSecret(cfg.Secret, http.DefaultServeMux),
+	}
+	go func() {
+		var err error
+		if cfg.CertFile != "" {
+			err = server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Webhook server error: %v", err)
+		}
+	}()
+	log.Printf("Listening for webhook on %s%s", cfg.Listen, path)
+
+	stop := func(ctx context.Context) error {
+		// Снимаем вебхук, чтобы Telegram копил обновления до следующего запуска
+		if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("Error deleting webhook: %v", err)
+		}
+		return server.Shutdown(ctx)
+	}
+	return updates, stop, nil
+}
+
+// requireSecret пропускает только запросы с правильным секретом вебхука
+func requireSecret(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(secretTokenHeader)
+		// Пустой секрет означает ошибку настройки, а не отключенную проверку
+		if secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestRequireSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		header   string
+		noHeader bool
+		want     int
+	}{
+		{name: "correct secret", secret: "s3cret", header: "s3cret", want: http.StatusOK},
+		{name: "wrong secret", secret: "s3cret", header: "other", want: http.StatusForbidden},
+		{name: "missing header", secret: "s3cret", noHeader: true, want: http.StatusForbidden},
+		{name: "empty secret", secret: "", header: "", want: http.StatusForbidden},
+		{name: "empty secret without header", secret: "", noHeader: true, want: http.StatusForbidden},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+			if !tt.noHeader {
+				req.Header.Set(secretTokenHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			requireSecret(tt.secret, next).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookConfigFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_URL", "https://example.com/bot")
+	t.Setenv("WEBHOOK_LISTEN", "")
+	t.Setenv("WEBHOOK_CERT", "")
+	t.Setenv("WEBHOOK_KEY", "")
+
+	t.Run("secret from env", func(t *testing.T) {
+		t.Setenv("WEBHOOK_SECRET", "s3cret")
+		cfg, err := webhookConfigFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Secret != "s3cret" || cfg.Listen != ":8443" {
+			t.Errorf("config = %+v", cfg)
+		}
+	})
+
+	t.Run("generated secret", func(t *testing.T) {
+		t.Setenv("WEBHOOK_SECRET", "")
+		first, err := webhookConfigFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := webhookConfigFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Telegram принимает в secret_token только A-Z, a-z, 0-9, _ и -
+		if !regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`).MatchString(first.Secret) {
+			t.Errorf("generated secret %q is not a valid secret_token", first.Secret)
+		}
+		if first.Secret == second.Secret {
+			t.Error("generated secrets are equal")
+		}
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		t.Setenv("WEBHOOK_URL", "")
+		if _, err := webhookConfigFromEnv(); err == nil {
+			t.Error("webhookConfigFromEnv() accepted empty WEBHOOK_URL")
+		}
+	})
+
+	t.Run("cert without key", func(t *testing.T) {
+		t.Setenv("WEBHOOK_CERT", "cert.pem")
+		if _, err := webhookConfigFromEnv(); err == nil {
+			t.Error("webhookConfigFromEnv() accepted WEBHOOK_CERT without WEBHOOK_KEY")
+		}
+	})
+}